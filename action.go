@@ -0,0 +1,86 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"net/http"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+const tracerName = "flamingo.me/opentelemetry"
+
+var (
+	handlerKey    = attribute.Key("flamingo.route.handler")
+	resultTypeKey = attribute.Key("flamingo.result.type")
+)
+
+type (
+	// actionConfig enables the controller action spans, only the listed route params are recorded
+	actionConfig struct {
+		Enable bool     `json:"enable"`
+		Params []string `json:"params"`
+	}
+
+	// actionTracer wraps the controller action in a child span of the request span, it is the last filter before the
+	// action so that the span does not include the other filters or the rendering of the result
+	actionTracer struct {
+		tracer trace.Tracer
+		params []string
+	}
+)
+
+var _ web.PrioritizedFilter = (*actionTracer)(nil)
+
+func newActionTracer(cfg actionConfig, provider trace.TracerProvider) *actionTracer {
+	return &actionTracer{tracer: provider.Tracer(tracerName), params: cfg.Params}
+}
+
+func (a *actionTracer) Filter(ctx context.Context, req *web.Request, w http.ResponseWriter, chain *web.FilterChain) web.Result {
+	handler := req.Handler()
+	if handler == nil || handler.GetHandlerName() == "" {
+		return chain.Next(ctx, req, w)
+	}
+
+	return a.trace(ctx, handler.GetHandlerName(), req.Params, func(ctx context.Context) web.Result {
+		return chain.Next(ctx, req, w)
+	})
+}
+
+// Priority runs the filter last
+func (*actionTracer) Priority() int {
+	return math.MinInt
+}
+
+func (a *actionTracer) trace(ctx context.Context, handlerName string, params web.RequestParams, action func(context.Context) web.Result) web.Result {
+	attrs := make([]attribute.KeyValue, 0, len(a.params)+1)
+	attrs = append(attrs, handlerKey.String(handlerName))
+
+	for _, name := range a.params {
+		if value, ok := params[name]; ok {
+			attrs = append(attrs, attribute.String("flamingo.route.param."+name, value))
+		}
+	}
+
+	ctx, span := a.tracer.Start(ctx, handlerName, trace.WithAttributes(attrs...))
+	defer span.End()
+
+	result := action(ctx)
+	if result == nil {
+		return nil
+	}
+
+	span.SetAttributes(resultTypeKey.String(fmt.Sprintf("%T", result)))
+
+	if serverError, ok := result.(*web.ServerErrorResponse); ok && serverError.Error != nil {
+		span.RecordError(serverError.Error)
+		span.SetStatus(codes.Error, serverError.Error.Error())
+	}
+
+	return result
+}
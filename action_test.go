@@ -0,0 +1,84 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private action tracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+var errAction = errors.New("product not found")
+
+func TestActionTracer(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name       string
+		result     web.Result
+		wantAttrs  []attribute.KeyValue
+		wantStatus codes.Code
+	}{
+		{
+			name:   "render result",
+			result: new(web.RenderResponse),
+			wantAttrs: []attribute.KeyValue{
+				handlerKey.String("product.view"),
+				attribute.String("flamingo.route.param.sku", "4711"),
+				resultTypeKey.String("*web.RenderResponse"),
+			},
+			wantStatus: codes.Unset,
+		},
+		{
+			name:   "server error",
+			result: &web.ServerErrorResponse{Error: errAction},
+			wantAttrs: []attribute.KeyValue{
+				handlerKey.String("product.view"),
+				attribute.String("flamingo.route.param.sku", "4711"),
+				resultTypeKey.String("*web.ServerErrorResponse"),
+			},
+			wantStatus: codes.Error,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exp := tracetest.NewInMemoryExporter()
+			tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exp))
+			tracer := &actionTracer{tracer: tp.Tracer("test"), params: []string{"sku", "variant"}}
+
+			ctx, parent := tp.Tracer("test").Start(context.Background(), "request")
+
+			var actionSpan trace.SpanContext
+
+			result := tracer.trace(ctx, "product.view", web.RequestParams{"sku": "4711", "session": "secret"}, func(ctx context.Context) web.Result {
+				actionSpan = trace.SpanContextFromContext(ctx)
+
+				return tt.result
+			})
+			parent.End()
+
+			assert.Same(t, tt.result, result)
+
+			spans := exp.GetSpans()
+			require.Len(t, spans, 2)
+
+			action := spans[0]
+			assert.Equal(t, "product.view", action.Name)
+			assert.Equal(t, actionSpan, action.SpanContext, "the action runs inside the span")
+			assert.Equal(t, parent.SpanContext().SpanID(), action.Parent.SpanID())
+			assert.ElementsMatch(t, tt.wantAttrs, action.Attributes)
+			assert.Equal(t, tt.wantStatus, action.Status.Code)
+		})
+	}
+}
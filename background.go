@@ -0,0 +1,130 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"go.opentelemetry.io/otel/metric"
+)
+
+type (
+	// component is started by the module and flushed and closed on shutdown, either function may be nil
+	component struct {
+		name  string
+		flush func(ctx context.Context) error
+		close func(ctx context.Context) error
+	}
+
+	// callbackMeterProvider remembers the callbacks registered through it, so that components which do not offer a way
+	// to stop them, e.g. the runtime metrics, can be stopped by unregistering their callbacks
+	callbackMeterProvider struct {
+		metric.MeterProvider
+
+		mu            sync.Mutex
+		registrations []metric.Registration
+	}
+
+	callbackMeter struct {
+		metric.Meter
+
+		provider *callbackMeterProvider
+	}
+)
+
+var (
+	_ metric.MeterProvider = (*callbackMeterProvider)(nil)
+	_ metric.Meter         = (*callbackMeter)(nil)
+)
+
+// register adds a component, components are closed in reverse order of their registration
+func (m *Module) register(c component) {
+	m.components = append(m.components, c)
+}
+
+// providerComponent flushes and shuts down a tracer or meter provider, if it supports it
+func providerComponent(name string, provider any) component {
+	c := component{name: name}
+
+	if f, ok := provider.(flusher); ok {
+		c.flush = f.ForceFlush
+	}
+
+	if s, ok := provider.(Shutdowner); ok {
+		c.close = s.Shutdown
+	}
+
+	return c
+}
+
+// flushComponents flushes the components in reverse order of their registration
+func flushComponents(ctx context.Context, components []component) error {
+	var errs []error
+
+	for i := len(components) - 1; i >= 0; i-- {
+		if components[i].flush == nil {
+			continue
+		}
+
+		if err := components[i].flush(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to flush %s: %w", components[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+// closeComponents closes the components in reverse order of their registration, a failed component does not stop
+// the remaining ones from being closed
+func closeComponents(ctx context.Context, components []component) error {
+	var errs []error
+
+	for i := len(components) - 1; i >= 0; i-- {
+		if components[i].close == nil {
+			continue
+		}
+
+		if err := components[i].close(ctx); err != nil {
+			errs = append(errs, fmt.Errorf("failed to close %s: %w", components[i].name, err))
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
+func (p *callbackMeterProvider) Meter(name string, options ...metric.MeterOption) metric.Meter {
+	return &callbackMeter{Meter: p.MeterProvider.Meter(name, options...), provider: p}
+}
+
+// unregister removes all callbacks registered through the provider
+func (p *callbackMeterProvider) unregister(context.Context) error {
+	p.mu.Lock()
+	registrations := p.registrations
+	p.registrations = nil
+	p.mu.Unlock()
+
+	errs := make([]error, 0, len(registrations))
+	for _, registration := range registrations {
+		errs = append(errs, registration.Unregister())
+	}
+
+	if err := errors.Join(errs...); err != nil {
+		return fmt.Errorf("failed to unregister callbacks: %w", err)
+	}
+
+	return nil
+}
+
+func (m *callbackMeter) RegisterCallback(f metric.Callback, instruments ...metric.Observable) (metric.Registration, error) {
+	registration, err := m.Meter.RegisterCallback(f, instruments...)
+	if err != nil {
+		return nil, err //nolint:wrapcheck // the meter is transparent to the caller
+	}
+
+	m.provider.mu.Lock()
+	m.provider.registrations = append(m.provider.registrations, registration)
+	m.provider.mu.Unlock()
+
+	return registration, nil
+}
@@ -0,0 +1,75 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private component handling
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/metric"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var errClose = errors.New("close failed")
+
+func TestCloseComponents(t *testing.T) {
+	t.Parallel()
+
+	var closed []string
+
+	closing := func(name string, err error) component {
+		return component{name: name, close: func(context.Context) error {
+			closed = append(closed, name)
+
+			return err
+		}}
+	}
+
+	err := closeComponents(context.Background(), []component{
+		closing("stdout file", nil),
+		{name: "without close"},
+		closing("meter provider", errClose),
+		closing("tracer provider", errClose),
+	})
+
+	assert.Equal(t, []string{"tracer provider", "meter provider", "stdout file"}, closed, "reverse order, failures do not stop")
+	require.ErrorIs(t, err, errClose)
+	assert.Equal(t, "failed to close tracer provider: close failed\nfailed to close meter provider: close failed", err.Error())
+}
+
+func TestCallbackMeterProvider(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkMetric.NewManualReader()
+	provider := &callbackMeterProvider{MeterProvider: sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader))}
+	meter := provider.Meter("test")
+
+	gauge, err := meter.Int64ObservableGauge("goroutines")
+	require.NoError(t, err)
+
+	_, err = meter.RegisterCallback(func(_ context.Context, o metric.Observer) error {
+		o.ObserveInt64(gauge, 1)
+
+		return nil
+	}, gauge)
+	require.NoError(t, err)
+
+	collect := func() int {
+		var rm metricdata.ResourceMetrics
+
+		require.NoError(t, reader.Collect(context.Background(), &rm))
+
+		if len(rm.ScopeMetrics) == 0 {
+			return 0
+		}
+
+		return len(rm.ScopeMetrics[0].Metrics)
+	}
+
+	assert.Equal(t, 1, collect())
+
+	require.NoError(t, provider.unregister(context.Background()))
+	assert.Equal(t, 0, collect(), "the callback is not called anymore")
+}
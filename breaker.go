@@ -0,0 +1,159 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+const (
+	circuitClosed circuitState = iota
+	circuitOpen
+	circuitHalfOpen
+
+	dropReasonCircuitOpen = "circuit_open"
+
+	defaultCoolDown = 30 * time.Second
+)
+
+var errCircuitOpen = errors.New("circuit breaker open, export skipped")
+
+type (
+	circuitState int
+
+	// circuitBreakerConfig enables the circuit breaker of an exporter, a threshold of 0 disables it
+	circuitBreakerConfig struct {
+		FailureThreshold int    `json:"failureThreshold"`
+		CoolDown         string `json:"coolDown"`
+	}
+
+	// circuitBreaker stops calling a failing exporter for a cool-down period after threshold consecutive failures,
+	// afterwards a single half-open probe decides whether the circuit closes again; it is independent of the signal
+	circuitBreaker struct {
+		name      string
+		threshold int
+		coolDown  time.Duration
+		logger    flamingo.Logger
+		now       func() time.Time
+
+		mu       sync.Mutex
+		state    circuitState
+		failures int
+		openedAt time.Time
+	}
+
+	// breakingSpanExporter skips the exporter while the circuit is open, the spans are dropped unless a spool follows
+	breakingSpanExporter struct {
+		next    tracesdk.SpanExporter
+		breaker *circuitBreaker
+		metrics *pipelineMetrics
+		// spooled skips counting the spans as dropped, the spool counts them as spooled
+		spooled bool
+	}
+)
+
+var _ tracesdk.SpanExporter = (*breakingSpanExporter)(nil)
+
+func newCircuitBreaker(cfg exporterConfig, logger flamingo.Logger) (*circuitBreaker, error) {
+	if cfg.CircuitBreaker.FailureThreshold < 0 {
+		return nil, fmt.Errorf("%w %s.circuitBreaker.failureThreshold: must not be negative", errInvalidExporter, cfg.configKey)
+	}
+
+	coolDown := defaultCoolDown
+
+	if cfg.CircuitBreaker.CoolDown != "" {
+		var err error
+
+		coolDown, err = time.ParseDuration(cfg.CircuitBreaker.CoolDown)
+		if err != nil || coolDown <= 0 {
+			return nil, fmt.Errorf("%w %s.circuitBreaker.coolDown: %q must be a positive duration", errInvalidExporter, cfg.configKey, cfg.CircuitBreaker.CoolDown)
+		}
+	}
+
+	return &circuitBreaker{
+		name:      cfg.Name,
+		threshold: cfg.CircuitBreaker.FailureThreshold,
+		coolDown:  coolDown,
+		logger: logger.
+			WithField(flamingo.LogKeyModule, "opentelemetry").
+			WithField(flamingo.LogKeyCategory, "circuit breaker"),
+		now: time.Now,
+	}, nil
+}
+
+// allow tells whether the exporter may be called, after the cool-down only one probe is allowed at a time
+func (b *circuitBreaker) allow() bool {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch b.state {
+	case circuitClosed:
+		return true
+	case circuitOpen:
+		if b.now().Sub(b.openedAt) < b.coolDown {
+			return false
+		}
+
+		b.state = circuitHalfOpen
+		b.logger.Infof("exporter %s: cool-down of %s over, probing with the next export", b.name, b.coolDown)
+
+		return true
+	case circuitHalfOpen:
+		// a probe is already running
+		return false
+	}
+
+	return false
+}
+
+// record updates the state with the result of an allowed call
+func (b *circuitBreaker) record(err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if err == nil {
+		if b.state != circuitClosed {
+			b.logger.Infof("exporter %s: export succeeded, circuit closed", b.name)
+		}
+
+		b.state = circuitClosed
+		b.failures = 0
+
+		return
+	}
+
+	b.failures++
+
+	if b.state == circuitHalfOpen || b.failures >= b.threshold {
+		b.logger.Warnf("exporter %s: circuit opened after %d consecutive failures, skipping exports for %s: %v",
+			b.name, b.failures, b.coolDown, err)
+
+		b.state = circuitOpen
+		b.openedAt = b.now()
+	}
+}
+
+func (e *breakingSpanExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if !e.breaker.allow() {
+		if !e.spooled {
+			e.metrics.recordDropped(e.breaker.name, dropReasonCircuitOpen, int64(len(spans)))
+		}
+
+		return errCircuitOpen
+	}
+
+	err := e.next.ExportSpans(ctx, spans)
+	e.breaker.record(err)
+
+	return err //nolint:wrapcheck // the error of the wrapped exporter is reported unchanged
+}
+
+func (e *breakingSpanExporter) Shutdown(ctx context.Context) error {
+	return e.next.Shutdown(ctx) //nolint:wrapcheck // the error of the wrapped exporter is reported unchanged
+}
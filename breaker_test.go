@@ -0,0 +1,128 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private circuit breaker
+
+import (
+	"context"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+)
+
+func TestBreakingSpanExporter(t *testing.T) {
+	t.Parallel()
+
+	metrics, reader := newTestPipelineMetrics(t)
+	logger := newRecordingLogger()
+	next := &outageExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+
+	breaker, err := newCircuitBreaker(exporterConfig{Name: "otlp", CircuitBreaker: circuitBreakerConfig{FailureThreshold: 2, CoolDown: "1m"}}, logger)
+	require.NoError(t, err)
+
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	exp := &breakingSpanExporter{next: next, breaker: breaker, metrics: metrics}
+	ctx := context.Background()
+
+	next.down.Store(true)
+	require.ErrorIs(t, exp.ExportSpans(ctx, recordSpans(t, "first")), errExport)
+	require.ErrorIs(t, exp.ExportSpans(ctx, recordSpans(t, "second")), errExport)
+
+	// open: the exporter is not called anymore
+	next.down.Store(false)
+	require.ErrorIs(t, exp.ExportSpans(ctx, recordSpans(t, "third", "fourth")), errCircuitOpen)
+	assert.Empty(t, next.GetSpans())
+	assert.Equal(t, int64(2), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.dropped",
+		exporterKey.String("otlp"), reasonKey.String(dropReasonCircuitOpen)))
+
+	// half-open: a failed probe opens the circuit again
+	now = now.Add(time.Minute)
+	next.down.Store(true)
+	require.ErrorIs(t, exp.ExportSpans(ctx, recordSpans(t, "probe")), errExport)
+	require.ErrorIs(t, exp.ExportSpans(ctx, recordSpans(t, "fifth")), errCircuitOpen)
+
+	// half-open: a successful probe closes the circuit
+	now = now.Add(time.Minute)
+	next.down.Store(false)
+	require.NoError(t, exp.ExportSpans(ctx, recordSpans(t, "probe")))
+	require.NoError(t, exp.ExportSpans(ctx, recordSpans(t, "sixth")))
+	assert.Len(t, next.GetSpans(), 2)
+
+	assert.Equal(t, []string{
+		"exporter otlp: circuit opened after 2 consecutive failures, skipping exports for 1m0s: export error",
+		"exporter otlp: cool-down of 1m0s over, probing with the next export",
+		"exporter otlp: circuit opened after 3 consecutive failures, skipping exports for 1m0s: export error",
+		"exporter otlp: cool-down of 1m0s over, probing with the next export",
+		"exporter otlp: export succeeded, circuit closed",
+	}, logger.Messages())
+}
+
+func TestBreakingSpanExporter_Spooled(t *testing.T) {
+	t.Parallel()
+
+	metrics, reader := newTestPipelineMetrics(t)
+	next := &outageExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+
+	cfg := exporterConfig{
+		Name:           "otlp",
+		CircuitBreaker: circuitBreakerConfig{FailureThreshold: 1},
+		Spool:          spoolConfig{Directory: t.TempDir()},
+	}
+
+	breaker, err := newCircuitBreaker(cfg, newRecordingLogger())
+	require.NoError(t, err)
+
+	spool, err := newSpoolingExporter(cfg, &breakingSpanExporter{next: next, breaker: breaker, metrics: metrics, spooled: true}, metrics, ignoreErrors)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, spool.Shutdown(context.Background())) })
+
+	exp := &observedExporter{name: "otlp", next: spool, metrics: metrics, queued: new(atomic.Int64)}
+	ctx := context.Background()
+
+	next.down.Store(true)
+	require.ErrorIs(t, exp.ExportSpans(ctx, recordSpans(t, "first")), errExport)
+	require.NoError(t, exp.ExportSpans(ctx, recordSpans(t, "second", "third")), "a skipped export is no failure")
+
+	assert.Equal(t, int64(3), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.spooled", exporterKey.String("otlp")))
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.failed", exporterKey.String("otlp")))
+	assert.Equal(t, int64(0), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.dropped",
+		exporterKey.String("otlp"), reasonKey.String(dropReasonCircuitOpen)), "spooled spans are not dropped")
+}
+
+func TestCircuitBreaker_HalfOpenAllowsOneProbe(t *testing.T) {
+	t.Parallel()
+
+	breaker, err := newCircuitBreaker(exporterConfig{Name: "otlp", CircuitBreaker: circuitBreakerConfig{FailureThreshold: 1}}, newRecordingLogger())
+	require.NoError(t, err)
+	assert.Equal(t, defaultCoolDown, breaker.coolDown)
+
+	now := time.Now()
+	breaker.now = func() time.Time { return now }
+
+	require.True(t, breaker.allow())
+	breaker.record(errExport)
+	assert.False(t, breaker.allow())
+
+	now = now.Add(defaultCoolDown)
+	assert.True(t, breaker.allow())
+	assert.False(t, breaker.allow(), "only one probe while half-open")
+
+	breaker.record(nil)
+	assert.True(t, breaker.allow())
+	assert.True(t, breaker.allow())
+}
+
+func TestNewCircuitBreaker_Invalid(t *testing.T) {
+	t.Parallel()
+
+	_, err := newCircuitBreaker(exporterConfig{configKey: "test", CircuitBreaker: circuitBreakerConfig{FailureThreshold: -1}}, newRecordingLogger())
+	require.ErrorIs(t, err, errInvalidExporter)
+	assert.ErrorContains(t, err, "test.circuitBreaker.failureThreshold")
+
+	_, err = newCircuitBreaker(exporterConfig{configKey: "test", CircuitBreaker: circuitBreakerConfig{FailureThreshold: 1, CoolDown: "soon"}}, newRecordingLogger())
+	require.ErrorIs(t, err, errInvalidExporter)
+	assert.ErrorContains(t, err, `test.circuitBreaker.coolDown: "soon" must be a positive duration`)
+}
@@ -0,0 +1,96 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"reflect"
+
+	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+)
+
+var (
+	errUnsupportedBridgeSampler = errors.New("unsupported OpenCensus sampler")
+	errForeignBridgeSpan        = errors.New("span was created by a different tracer")
+)
+
+type (
+	// bridgeTracer reports the errors of the OpenCensus trace bridge as bridge errors, the bridge itself passes them
+	// to the global error handler without a type
+	bridgeTracer struct {
+		octrace.Tracer
+		errors   otel.ErrorHandler
+		spanType reflect.Type
+	}
+
+	// bridgeMetricProducer marks the errors of the OpenCensus metric producer as bridge errors
+	bridgeMetricProducer struct {
+		sdkMetric.Producer
+	}
+)
+
+var (
+	_ octrace.Tracer     = (*bridgeTracer)(nil)
+	_ sdkMetric.Producer = bridgeMetricProducer{}
+)
+
+func newBridgeTracer(bridge octrace.Tracer, errorHandler otel.ErrorHandler) *bridgeTracer {
+	return &bridgeTracer{
+		Tracer:   bridge,
+		errors:   errorHandler,
+		spanType: reflect.TypeOf(bridge.FromContext(context.Background()).Internal()),
+	}
+}
+
+func (b *bridgeTracer) StartSpan(ctx context.Context, name string, o ...octrace.StartOption) (context.Context, *octrace.Span) {
+	return b.Tracer.StartSpan(ctx, name, b.options(name, o)...)
+}
+
+func (b *bridgeTracer) StartSpanWithRemoteParent(ctx context.Context, name string, parent octrace.SpanContext, o ...octrace.StartOption) (context.Context, *octrace.Span) {
+	return b.Tracer.StartSpanWithRemoteParent(ctx, name, parent, b.options(name, o)...)
+}
+
+// NewContext keeps the parent context for spans of other tracers, like the bridge does
+func (b *bridgeTracer) NewContext(parent context.Context, s *octrace.Span) context.Context {
+	if reflect.TypeOf(s.Internal()) != b.spanType {
+		b.errors.Handle(&categorizedError{
+			category: errorCategoryBridge,
+			err:      fmt.Errorf("unable to create context with span %q: %w", s.String(), errForeignBridgeSpan),
+		})
+
+		return parent
+	}
+
+	return b.Tracer.NewContext(parent, s)
+}
+
+// options drops the OpenCensus sampler, which the bridge ignores as well
+func (b *bridgeTracer) options(name string, o []octrace.StartOption) []octrace.StartOption {
+	var opts octrace.StartOptions
+	for _, fn := range o {
+		fn(&opts)
+	}
+
+	if opts.Sampler == nil {
+		return o
+	}
+
+	b.errors.Handle(&categorizedError{
+		category: errorCategoryBridge,
+		err:      fmt.Errorf("starting span %q: %w", name, errUnsupportedBridgeSampler),
+	})
+
+	return []octrace.StartOption{octrace.WithSpanKind(opts.SpanKind)}
+}
+
+func (p bridgeMetricProducer) Produce(ctx context.Context) ([]metricdata.ScopeMetrics, error) {
+	metrics, err := p.Producer.Produce(ctx)
+	if err != nil {
+		return metrics, &categorizedError{category: errorCategoryBridge, err: fmt.Errorf("failed to produce OpenCensus metrics: %w", err)}
+	}
+
+	return metrics, nil
+}
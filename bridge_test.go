@@ -0,0 +1,83 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private bridge wrappers
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	octrace "go.opencensus.io/trace"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/bridge/opencensus"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// foreignSpan stands for a span of another OpenCensus tracer
+type foreignSpan struct {
+	octrace.SpanInterface
+}
+
+func (foreignSpan) String() string { return "foreign" }
+
+// testBridgeTracer returns the OpenCensus trace bridge without installing it as the global OpenCensus tracer
+func testBridgeTracer(t *testing.T, provider trace.TracerProvider) octrace.Tracer {
+	t.Helper()
+
+	previous := octrace.DefaultTracer
+	opencensus.InstallTraceBridge(opencensus.WithTracerProvider(provider))
+
+	bridge := octrace.DefaultTracer
+	octrace.DefaultTracer = previous
+
+	return bridge
+}
+
+func TestBridgeTracer(t *testing.T) { //nolint:paralleltest // swaps the global OpenCensus tracer
+	exp := tracetest.NewInMemoryExporter()
+	provider := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exp))
+
+	var handled []error
+
+	tracer := newBridgeTracer(testBridgeTracer(t, provider), otel.ErrorHandlerFunc(func(err error) {
+		handled = append(handled, err)
+	}))
+
+	ctx, span := tracer.StartSpan(context.Background(), "legacy", octrace.WithSampler(octrace.AlwaysSample()),
+		octrace.WithSpanKind(octrace.SpanKindClient))
+	span.End()
+
+	require.Len(t, exp.GetSpans(), 1)
+	assert.Equal(t, trace.SpanKindClient, exp.GetSpans()[0].SpanKind, "the other options are kept")
+	require.Len(t, handled, 1)
+	assert.ErrorIs(t, handled[0], errUnsupportedBridgeSampler)
+	assert.Equal(t, errorCategoryBridge, classifyError(handled[0]))
+
+	assert.Equal(t, ctx, tracer.NewContext(ctx, octrace.NewSpan(foreignSpan{})), "spans of other tracers are not attached")
+	require.Len(t, handled, 2)
+	assert.ErrorIs(t, handled[1], errForeignBridgeSpan)
+	assert.Equal(t, errorCategoryBridge, classifyError(handled[1]))
+
+	assert.Equal(t, exp.GetSpans()[0].SpanContext, trace.SpanContextFromContext(tracer.NewContext(context.Background(), span)),
+		"spans of the bridge are attached")
+	assert.Len(t, handled, 2)
+}
+
+type failingProducer struct{}
+
+func (failingProducer) Produce(context.Context) ([]metricdata.ScopeMetrics, error) {
+	return nil, errExport
+}
+
+func TestBridgeMetricProducer(t *testing.T) {
+	t.Parallel()
+
+	var producer sdkMetric.Producer = bridgeMetricProducer{Producer: failingProducer{}}
+
+	_, err := producer.Produce(context.Background())
+	require.ErrorIs(t, err, errExport)
+	assert.Equal(t, errorCategoryBridge, classifyError(err))
+}
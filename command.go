@@ -0,0 +1,259 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/spf13/cobra"
+	"github.com/spf13/pflag"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/metric"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+const (
+	// tracedAnnotation marks commands whose run is already wrapped
+	tracedAnnotation = "flamingo.me/opentelemetry/traced"
+	redacted         = "***"
+	flushTimeout     = 5 * time.Second
+)
+
+var (
+	errInvalidCommandSampleRatio = errors.New("invalid flamingo.opentelemetry.commands.sampleRatio")
+
+	commandKey     = attribute.Key("flamingo.command")
+	commandArgsKey = attribute.Key("flamingo.command.args")
+	outcomeKey     = attribute.Key("outcome")
+)
+
+type (
+	// commandConfig configures the root spans of CLI commands, excluded commands like serve are not wrapped
+	commandConfig struct {
+		Enable      bool     `json:"enable"`
+		SampleRatio float64  `json:"sampleRatio"`
+		Exclude     []string `json:"exclude"`
+		Redact      []string `json:"redact"`
+	}
+
+	// commandTracer wraps the runs of all commands in a root span and flushes the telemetry once the run is done,
+	// the hidden anchor command is added to the command tree by flamingo and leads to the root command
+	commandTracer struct {
+		cfg            commandConfig
+		anchor         *cobra.Command
+		tracer         trace.Tracer
+		duration       metric.Float64Histogram
+		tracerProvider trace.TracerProvider
+		meterProvider  metric.MeterProvider
+		errors         otel.ErrorHandler
+	}
+
+	// commandSampler samples command runs by their own ratio, independent of the URL sampler
+	commandSampler struct {
+		base     tracesdk.Sampler
+		commands tracesdk.Sampler
+		metrics  *pipelineMetrics
+	}
+
+	flusher interface {
+		ForceFlush(ctx context.Context) error
+	}
+)
+
+var (
+	_ flamingo.Subscriber = (*commandTracer)(nil)
+	_ tracesdk.Sampler    = (*commandSampler)(nil)
+)
+
+func newCommandTracer(cfg commandConfig, errorHandler otel.ErrorHandler) *commandTracer {
+	return &commandTracer{
+		cfg:    cfg,
+		errors: errorHandler,
+		anchor: &cobra.Command{
+			Use:    "opentelemetry",
+			Short:  "anchor of the OpenTelemetry command tracing",
+			Hidden: true,
+		},
+	}
+}
+
+// Inject dependencies
+func (c *commandTracer) Inject(tracerProvider trace.TracerProvider, meterProvider metric.MeterProvider) *commandTracer {
+	duration, err := meterProvider.Meter(meterName).Float64Histogram(
+		"flamingo.opentelemetry.command.duration",
+		metric.WithDescription("Duration of CLI command runs"),
+		metric.WithUnit("s"),
+	)
+	if err != nil {
+		c.errors.Handle(&categorizedError{category: errorCategoryMetrics, err: fmt.Errorf("failed to create command metrics: %w", err)})
+	}
+
+	c.tracer = tracerProvider.Tracer(tracerName)
+	c.duration = duration
+	c.tracerProvider = tracerProvider
+	c.meterProvider = meterProvider
+
+	return c
+}
+
+// Notify wraps the commands on startup, which happens before the selected command runs
+func (c *commandTracer) Notify(_ context.Context, event flamingo.Event) {
+	if _, ok := event.(*flamingo.StartupEvent); !ok {
+		return
+	}
+
+	root := c.anchor.Root()
+	if root == c.anchor {
+		return
+	}
+
+	c.instrument(root)
+}
+
+// instrument wraps all runnable commands of the tree, repeated calls do not wrap them again
+func (c *commandTracer) instrument(cmd *cobra.Command) {
+	for _, sub := range cmd.Commands() {
+		c.instrument(sub)
+	}
+
+	if !cmd.Runnable() || slices.Contains(c.cfg.Exclude, cmd.Name()) || cmd.Annotations[tracedAnnotation] != "" {
+		return
+	}
+
+	run := cmd.RunE
+	if run == nil {
+		plain := cmd.Run
+		run = func(cmd *cobra.Command, args []string) error {
+			plain(cmd, args)
+
+			return nil
+		}
+		cmd.Run = nil
+	}
+
+	cmd.RunE = func(cmd *cobra.Command, args []string) error {
+		return c.run(cmd, args, run)
+	}
+
+	if cmd.Annotations == nil {
+		cmd.Annotations = make(map[string]string, 1)
+	}
+
+	cmd.Annotations[tracedAnnotation] = "true"
+}
+
+func (c *commandTracer) run(cmd *cobra.Command, args []string, run func(*cobra.Command, []string) error) error {
+	ctx := cmd.Context()
+	if ctx == nil {
+		ctx = context.Background()
+	}
+
+	name := cmd.CommandPath()
+
+	ctx, span := c.tracer.Start(ctx, name,
+		trace.WithNewRoot(),
+		trace.WithAttributes(commandKey.String(name), commandArgsKey.StringSlice(c.redactArgs(cmd, args))),
+	)
+	cmd.SetContext(ctx)
+
+	start := time.Now()
+	err := run(cmd, args)
+	outcome := "ok"
+
+	if err != nil {
+		outcome = "error"
+
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+	}
+
+	span.End()
+
+	if c.duration != nil {
+		c.duration.Record(ctx, time.Since(start).Seconds(), metric.WithAttributes(commandKey.String(name), outcomeKey.String(outcome)))
+	}
+
+	c.flush()
+
+	return err
+}
+
+// redactArgs returns the set flags and the arguments, values of flags and key=value arguments whose name contains
+// one of the redact terms are replaced
+func (c *commandTracer) redactArgs(cmd *cobra.Command, args []string) []string {
+	sensitive := func(name string) bool {
+		name = strings.ToLower(name)
+
+		return slices.ContainsFunc(c.cfg.Redact, func(term string) bool { return strings.Contains(name, strings.ToLower(term)) })
+	}
+
+	result := make([]string, 0, len(args))
+
+	cmd.Flags().Visit(func(flag *pflag.Flag) {
+		value := flag.Value.String()
+		if sensitive(flag.Name) {
+			value = redacted
+		}
+
+		result = append(result, "--"+flag.Name+"="+value)
+	})
+
+	for _, arg := range args {
+		if key, _, ok := strings.Cut(arg, "="); ok && sensitive(key) {
+			arg = key + "=" + redacted
+		}
+
+		result = append(result, arg)
+	}
+
+	return result
+}
+
+// flush exports the telemetry of the run, the process may exit right after the command
+func (c *commandTracer) flush() {
+	ctx, cancel := context.WithTimeout(context.Background(), flushTimeout)
+	defer cancel()
+
+	for _, provider := range []any{c.tracerProvider, c.meterProvider} {
+		if f, ok := provider.(flusher); ok {
+			if err := f.ForceFlush(ctx); err != nil {
+				c.errors.Handle(fmt.Errorf("failed to flush %T after the command: %w", provider, err))
+			}
+		}
+	}
+}
+
+func newCommandSampler(base tracesdk.Sampler, cfg commandConfig, metrics *pipelineMetrics) (*commandSampler, error) {
+	if cfg.SampleRatio < 0 || cfg.SampleRatio > 1 {
+		return nil, &categorizedError{
+			category: errorCategorySampler,
+			err:      fmt.Errorf("%w: %v must be between 0 and 1", errInvalidCommandSampleRatio, cfg.SampleRatio),
+		}
+	}
+
+	return &commandSampler{base: base, commands: tracesdk.TraceIDRatioBased(cfg.SampleRatio), metrics: metrics}, nil
+}
+
+func (s *commandSampler) ShouldSample(params tracesdk.SamplingParameters) tracesdk.SamplingResult {
+	isCommand := slices.ContainsFunc(params.Attributes, func(attr attribute.KeyValue) bool { return attr.Key == commandKey })
+	if !isCommand || trace.SpanContextFromContext(params.ParentContext).IsValid() {
+		return s.base.ShouldSample(params)
+	}
+
+	result := s.commands.ShouldSample(params)
+	s.metrics.recordSamplingDecision(params.ParentContext, result.Decision, samplerRuleCommand)
+
+	return result
+}
+
+func (s *commandSampler) Description() string {
+	return fmt.Sprintf("CommandSampler{base:%s,commands:%s}", s.base.Description(), s.commands.Description())
+}
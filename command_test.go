@@ -0,0 +1,123 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private command tracer
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/spf13/cobra"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+var errImport = errors.New("import failed")
+
+func TestCommandTracer(t *testing.T) {
+	t.Parallel()
+
+	exp := tracetest.NewInMemoryExporter()
+	reader := sdkMetric.NewManualReader()
+
+	commands := newCommandTracer(commandConfig{Enable: true, Exclude: []string{"serve"}, Redact: []string{"password", "key"}}, ignoreErrors).Inject(
+		tracesdk.NewTracerProvider(tracesdk.WithSyncer(exp)),
+		sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)),
+	)
+
+	var (
+		importSpan trace.SpanContext
+		served     bool
+	)
+
+	importCmd := &cobra.Command{
+		Use: "import",
+		Run: func(cmd *cobra.Command, _ []string) {
+			importSpan = trace.SpanContextFromContext(cmd.Context())
+		},
+	}
+	importCmd.Flags().String("password", "", "")
+	importCmd.Flags().Int("limit", 0, "")
+
+	root := &cobra.Command{Use: "shop", SilenceErrors: true, SilenceUsage: true}
+	root.AddCommand(
+		importCmd,
+		&cobra.Command{Use: "index", RunE: func(*cobra.Command, []string) error { return errImport }},
+		&cobra.Command{Use: "serve", Run: func(*cobra.Command, []string) { served = true }},
+		commands.anchor,
+	)
+
+	commands.Notify(context.Background(), &flamingo.StartupEvent{})
+	commands.Notify(context.Background(), &flamingo.StartupEvent{})
+
+	root.SetArgs([]string{"import", "--password=secret", "--limit=5", "api-key=abc", "products.csv"})
+	require.NoError(t, root.Execute())
+
+	root.SetArgs([]string{"index"})
+	require.ErrorIs(t, root.Execute(), errImport)
+
+	root.SetArgs([]string{"serve"})
+	require.NoError(t, root.Execute())
+	assert.True(t, served)
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2, "one span per traced run, serve is excluded")
+
+	assert.Equal(t, "shop import", spans[0].Name)
+	assert.Equal(t, importSpan, spans[0].SpanContext, "the command runs inside the span")
+	assert.False(t, spans[0].Parent.IsValid())
+	assert.Contains(t, spans[0].Attributes, commandArgsKey.StringSlice([]string{
+		"--limit=5", "--password=***", "api-key=***", "products.csv",
+	}))
+
+	assert.Equal(t, "shop index", spans[1].Name)
+	assert.Equal(t, codes.Error, spans[1].Status.Code)
+
+	var rm metricdata.ResourceMetrics
+
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+	require.Len(t, rm.ScopeMetrics, 1)
+
+	histogram, ok := rm.ScopeMetrics[0].Metrics[0].Data.(metricdata.Histogram[float64])
+	require.True(t, ok)
+	assert.Len(t, histogram.DataPoints, 2)
+
+	for _, dp := range histogram.DataPoints {
+		outcome, _ := dp.Attributes.Value(outcomeKey)
+		command, _ := dp.Attributes.Value(commandKey)
+		assert.Equal(t, map[string]string{"shop import": "ok", "shop index": "error"}[command.AsString()], outcome.AsString())
+	}
+}
+
+func TestCommandSampler(t *testing.T) {
+	t.Parallel()
+
+	metrics, reader := newTestPipelineMetrics(t)
+
+	sampler, err := newCommandSampler(tracesdk.AlwaysSample(), commandConfig{SampleRatio: 0}, metrics)
+	require.NoError(t, err)
+
+	exp := tracetest.NewInMemoryExporter()
+	tracer := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exp), tracesdk.WithSampler(sampler)).Tracer("test")
+
+	_, command := tracer.Start(context.Background(), "shop import", trace.WithAttributes(commandKey.String("shop import")))
+	command.End()
+
+	_, other := tracer.Start(context.Background(), "other")
+	other.End()
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 1)
+	assert.Equal(t, "other", spans[0].Name, "commands are sampled by their own ratio")
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.sampler.decisions",
+		decisionKey.String("drop"), ruleKey.String(samplerRuleCommand)))
+
+	_, err = newCommandSampler(tracesdk.AlwaysSample(), commandConfig{SampleRatio: 2}, metrics)
+	require.ErrorIs(t, err, errInvalidCommandSampleRatio)
+}
@@ -0,0 +1,135 @@
+package opentelemetry
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/url"
+	"strings"
+
+	"go.opentelemetry.io/otel/propagation"
+)
+
+var (
+	errInvalidEndpoint       = errors.New("invalid endpoint")
+	errUnknownPropagator     = errors.New("unknown propagator")
+	errInvalidSamplerPattern = errors.New("invalid sampler pattern")
+)
+
+// knownPropagators maps the propagator names of the OpenTelemetry specification to their implementation
+var knownPropagators = map[string]propagation.TextMapPropagator{
+	"tracecontext": propagation.TraceContext{},
+	"baggage":      propagation.Baggage{},
+}
+
+// ConfigurationError collects all problems found while setting up the module
+type ConfigurationError struct {
+	Problems []error
+}
+
+var _ error = (*ConfigurationError)(nil)
+
+// newConfigurationError returns a ConfigurationError for all non-nil errors, or nil if there are none
+func newConfigurationError(errs ...error) error {
+	var problems []error
+
+	for _, err := range errs {
+		problems = appendProblems(problems, err)
+	}
+
+	if len(problems) == 0 {
+		return nil
+	}
+
+	return &ConfigurationError{Problems: problems}
+}
+
+// appendProblems flattens joined errors so that every problem is reported on its own
+func appendProblems(problems []error, err error) []error {
+	if err == nil {
+		return problems
+	}
+
+	if joined, ok := err.(interface{ Unwrap() []error }); ok { //nolint:errorlint // only errors.Join results are flattened
+		for _, e := range joined.Unwrap() {
+			problems = appendProblems(problems, e)
+		}
+
+		return problems
+	}
+
+	return append(problems, err)
+}
+
+func (e *ConfigurationError) Error() string {
+	var b strings.Builder
+
+	b.WriteString("invalid opentelemetry configuration:")
+
+	for _, p := range e.Problems {
+		b.WriteString("\n\t- ")
+		b.WriteString(p.Error())
+	}
+
+	return b.String()
+}
+
+func (e *ConfigurationError) Unwrap() []error {
+	return e.Problems
+}
+
+// parseEndpointURL parses an endpoint which must be an absolute http(s) URL
+func parseEndpointURL(key, endpoint string) (*url.URL, error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", errInvalidEndpoint, key, err)
+	}
+
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w %s: %q must be an absolute http or https URL", errInvalidEndpoint, key, endpoint)
+	}
+
+	return u, nil
+}
+
+// validateGRPCEndpoint accepts either a host:port pair or an URL with a host
+func validateGRPCEndpoint(key, endpoint string) error {
+	if strings.Contains(endpoint, "://") {
+		u, err := url.Parse(endpoint)
+		if err != nil {
+			return fmt.Errorf("%w %s: %w", errInvalidEndpoint, key, err)
+		}
+
+		if u.Host == "" {
+			return fmt.Errorf("%w %s: %q has no host", errInvalidEndpoint, key, endpoint)
+		}
+
+		return nil
+	}
+
+	if _, _, err := net.SplitHostPort(endpoint); err != nil {
+		return fmt.Errorf("%w %s: %w", errInvalidEndpoint, key, err)
+	}
+
+	return nil
+}
+
+// newPropagator composes the named propagators, unknown names are reported with the source of the names and skipped
+func newPropagator(names []string, source string) (propagation.TextMapPropagator, error) {
+	var errs []error
+
+	propagators := make([]propagation.TextMapPropagator, 0, len(names))
+
+	for _, name := range names {
+		p, ok := knownPropagators[name]
+		if !ok {
+			errs = append(errs, fmt.Errorf("%w %q in %s", errUnknownPropagator, name, source))
+
+			continue
+		}
+
+		propagators = append(propagators, p)
+	}
+
+	return propagation.NewCompositeTextMapPropagator(propagators...), errors.Join(errs...)
+}
@@ -0,0 +1,99 @@
+package opentelemetry //nolint:testpackage // explicit testing of private configuration helpers
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewConfigurationError(t *testing.T) {
+	t.Parallel()
+
+	t.Run("no problems", func(t *testing.T) {
+		t.Parallel()
+
+		assert.NoError(t, newConfigurationError(nil, errors.Join(nil, nil)))
+	})
+
+	t.Run("joined problems are flattened", func(t *testing.T) {
+		t.Parallel()
+
+		errA := errors.New("a")
+		errB := errors.New("b")
+		errC := errors.New("c")
+
+		err := newConfigurationError(errors.Join(errA, errors.Join(errB, nil)), nil, errC)
+
+		var configErr *ConfigurationError
+
+		require.ErrorAs(t, err, &configErr)
+		assert.Equal(t, []error{errA, errB, errC}, configErr.Problems)
+		assert.ErrorIs(t, err, errB)
+		assert.Equal(t, "invalid opentelemetry configuration:\n\t- a\n\t- b\n\t- c", err.Error())
+	})
+}
+
+func TestParseEndpointURL(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name     string
+		endpoint string
+		wantErr  bool
+	}{
+		{name: "http", endpoint: "http://localhost:4318/v1/traces"},
+		{name: "https", endpoint: "https://collector.example.com/v1/traces"},
+		{name: "unparsable", endpoint: "http://local host:%", wantErr: true},
+		{name: "relative", endpoint: "/v1/traces", wantErr: true},
+		{name: "other scheme", endpoint: "ftp://localhost/v1/traces", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			_, err := parseEndpointURL("key", tt.endpoint)
+			if tt.wantErr {
+				assert.ErrorIs(t, err, errInvalidEndpoint)
+			} else {
+				assert.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestValidateGRPCEndpoint(t *testing.T) {
+	t.Parallel()
+
+	assert.NoError(t, validateGRPCEndpoint("key", "localhost:4317"))
+	assert.NoError(t, validateGRPCEndpoint("key", "grpc://localhost:4317/v1/traces"))
+	assert.ErrorIs(t, validateGRPCEndpoint("key", "localhost"), errInvalidEndpoint)
+	assert.ErrorIs(t, validateGRPCEndpoint("key", "grpc:///v1/traces"), errInvalidEndpoint)
+}
+
+func TestNewPropagator(t *testing.T) {
+	t.Parallel()
+
+	propagator, err := newPropagator([]string{"tracecontext", "unknown", "baggage", "b4"}, "OTEL_PROPAGATORS")
+
+	require.ErrorIs(t, err, errUnknownPropagator)
+	assert.ErrorContains(t, err, `unknown propagator "unknown" in OTEL_PROPAGATORS`)
+	assert.Len(t, appendProblems(nil, err), 2)
+	assert.ElementsMatch(t, []string{"traceparent", "tracestate", "baggage"}, propagator.Fields())
+}
+
+func TestConfiguredURLPrefixSampler_Validate(t *testing.T) {
+	t.Parallel()
+
+	sampler := &configuredURLPrefixSampler{
+		allowlist: []string{"/valid", "invalid"},
+		blocklist: []string{"", "/static"},
+	}
+
+	err := sampler.validate()
+
+	require.ErrorIs(t, err, errInvalidSamplerPattern)
+	assert.Len(t, appendProblems(nil, err), 2)
+}
@@ -0,0 +1,195 @@
+package opentelemetry
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	defaultServiceName      = "flamingo"
+	defaultZipkinEndpoint   = "http://localhost:9411/api/v2/spans"
+	defaultOTLPHTTPEndpoint = "http://localhost:4318/v1/traces"
+	defaultOTLPGRPCEndpoint = "grpc://localhost:4317/v1/traces"
+)
+
+var (
+	defaultPropagators = []string{"tracecontext", "baggage"}
+
+	errUnsupportedSampler = errors.New("unsupported sampler")
+	errInvalidSamplerArg  = errors.New("invalid sampler argument")
+	errUnsupportedOTLP    = errors.New("unsupported OTLP protocol")
+)
+
+// applyEnvironment fills all settings not configured in flamingo.opentelemetry.* from the standard
+// OpenTelemetry environment variables, before falling back to the module defaults. Settings left at their default
+// count as not configured, the enable flags of the single exporters only if they are not set at all.
+func (m *Module) applyEnvironment(lookup func(key string) (string, bool)) error {
+	env := func(keys ...string) string {
+		for _, key := range keys {
+			if v, ok := lookup(key); ok && strings.TrimSpace(v) != "" {
+				return strings.TrimSpace(v)
+			}
+		}
+
+		return ""
+	}
+
+	// fill sets the value unless it is configured
+	fill := func(value *string, defaultValue, envValue string) {
+		if *value == "" || *value == defaultValue {
+			*value = firstNonEmpty(envValue, defaultValue)
+		}
+	}
+
+	var errs []error
+
+	fill(&m.serviceName, defaultServiceName, env("OTEL_SERVICE_NAME"))
+
+	if m.propagators == nil || slices.Equal(m.propagators, defaultPropagators) {
+		m.propagators = defaultPropagators
+
+		if propagators := env("OTEL_PROPAGATORS"); propagators != "" {
+			m.propagators = splitList(propagators)
+			m.propagatorsSource = "OTEL_PROPAGATORS"
+		}
+	}
+
+	exportersSet := env("OTEL_TRACES_EXPORTER") != ""
+	exporters := splitList(env("OTEL_TRACES_EXPORTER"))
+
+	otlpEndpoint := env("OTEL_EXPORTER_OTLP_TRACES_ENDPOINT")
+	otlpProtocol := firstNonEmpty(env("OTEL_EXPORTER_OTLP_TRACES_PROTOCOL", "OTEL_EXPORTER_OTLP_PROTOCOL"), "http/protobuf")
+
+	if base := env("OTEL_EXPORTER_OTLP_ENDPOINT"); otlpEndpoint == "" && base != "" {
+		otlpEndpoint = base
+		// the signal specific path is only appended for OTLP over HTTP
+		if otlpProtocol != "grpc" {
+			otlpEndpoint = strings.TrimSuffix(base, "/") + "/v1/traces"
+		}
+	}
+
+	var otlpHTTPEndpoint, otlpGRPCEndpoint string
+
+	// without OTEL_TRACES_EXPORTER a configured OTLP endpoint is enough to enable the exporter
+	if slices.Contains(exporters, "otlp") || (!exportersSet && otlpEndpoint != "") {
+		switch otlpProtocol {
+		case "http/protobuf", "http/json":
+			m.otlpEnableHTTP = enableUnset(m.otlpEnableHTTP)
+			otlpHTTPEndpoint = otlpEndpoint
+
+			if otlpProtocol == "http/json" && (m.otlpHTTPOptions.Encoding == "" || m.otlpHTTPOptions.Encoding == encodingProtobuf) {
+				m.otlpHTTPOptions.Encoding = encodingJSON
+			}
+		case "grpc":
+			m.otlpEnableGRPC = enableUnset(m.otlpEnableGRPC)
+			otlpGRPCEndpoint = otlpEndpoint
+		default:
+			errs = append(errs, fmt.Errorf("%w %q in OTEL_EXPORTER_OTLP_PROTOCOL", errUnsupportedOTLP, otlpProtocol))
+		}
+	}
+
+	fill(&m.otlpEndpointHTTP, defaultOTLPHTTPEndpoint, otlpHTTPEndpoint)
+	fill(&m.otlpEndpointGRPC, defaultOTLPGRPCEndpoint, otlpGRPCEndpoint)
+
+	// the custom client of the OTLP HTTP exporter ignores its own TLS settings, they apply to the shared transport
+	fill(&m.exporterTransport.Certificate, "", env("OTEL_EXPORTER_OTLP_TRACES_CERTIFICATE", "OTEL_EXPORTER_OTLP_CERTIFICATE"))
+	fill(&m.exporterTransport.ClientCertificate, "",
+		env("OTEL_EXPORTER_OTLP_TRACES_CLIENT_CERTIFICATE", "OTEL_EXPORTER_OTLP_CLIENT_CERTIFICATE"))
+	fill(&m.exporterTransport.ClientKey, "", env("OTEL_EXPORTER_OTLP_TRACES_CLIENT_KEY", "OTEL_EXPORTER_OTLP_CLIENT_KEY"))
+
+	if slices.Contains(exporters, "zipkin") {
+		m.zipkinEnable = enableUnset(m.zipkinEnable)
+	}
+
+	fill(&m.zipkinEndpoint, defaultZipkinEndpoint, env("OTEL_EXPORTER_ZIPKIN_ENDPOINT"))
+
+	if name := env("OTEL_TRACES_SAMPLER"); name != "" {
+		sampler, err := samplerFromEnv(name, env("OTEL_TRACES_SAMPLER_ARG"))
+		if err != nil {
+			errs = append(errs, err)
+		}
+
+		m.sampler.root = sampler
+	}
+
+	return errors.Join(errs...)
+}
+
+// enableUnset enables an exporter unless its enable flag is configured
+func enableUnset(flag *bool) *bool {
+	if flag != nil {
+		return flag
+	}
+
+	enabled := true
+
+	return &enabled
+}
+
+// isEnabled reports whether an optional enable flag is set to true
+func isEnabled(flag *bool) bool {
+	return flag != nil && *flag
+}
+
+// samplerFromEnv creates the sampler named in OTEL_TRACES_SAMPLER, unsupported samplers are reported and ignored
+func samplerFromEnv(name, arg string) (tracesdk.Sampler, error) {
+	ratio := 1.0
+
+	if arg != "" && strings.HasSuffix(name, "traceidratio") {
+		var err error
+
+		ratio, err = strconv.ParseFloat(arg, 64)
+		if err != nil || ratio < 0 || ratio > 1 {
+			return nil, &categorizedError{
+				category: errorCategorySampler,
+				err:      fmt.Errorf("%w %q in OTEL_TRACES_SAMPLER_ARG: must be a ratio between 0 and 1", errInvalidSamplerArg, arg),
+			}
+		}
+	}
+
+	switch name {
+	case "always_on":
+		return tracesdk.AlwaysSample(), nil
+	case "always_off":
+		return tracesdk.NeverSample(), nil
+	case "traceidratio":
+		return tracesdk.TraceIDRatioBased(ratio), nil
+	case "parentbased_always_on":
+		return tracesdk.ParentBased(tracesdk.AlwaysSample()), nil
+	case "parentbased_always_off":
+		return tracesdk.ParentBased(tracesdk.NeverSample()), nil
+	case "parentbased_traceidratio":
+		return tracesdk.ParentBased(tracesdk.TraceIDRatioBased(ratio)), nil
+	}
+
+	return nil, &categorizedError{category: errorCategorySampler, err: fmt.Errorf("%w %q in OTEL_TRACES_SAMPLER", errUnsupportedSampler, name)}
+}
+
+func firstNonEmpty(values ...string) string {
+	for _, v := range values {
+		if v != "" {
+			return v
+		}
+	}
+
+	return ""
+}
+
+// splitList splits a comma separated environment variable, "none" results in an empty list
+func splitList(value string) []string {
+	var list []string
+
+	for _, v := range strings.Split(value, ",") {
+		v = strings.TrimSpace(v)
+		if v != "" && v != "none" {
+			list = append(list, v)
+		}
+	}
+
+	return list
+}
@@ -0,0 +1,184 @@
+package opentelemetry //nolint:testpackage // explicit testing of private configuration fields
+
+import (
+	"os"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestModule_ApplyEnvironment(t *testing.T) { //nolint:paralleltest // t.Setenv does not allow parallel tests
+	t.Run("module defaults without environment", func(t *testing.T) {
+		m := &Module{sampler: new(configuredURLPrefixSampler)}
+
+		require.NoError(t, m.applyEnvironment(func(string) (string, bool) { return "", false }))
+
+		assert.Equal(t, defaultServiceName, m.serviceName)
+		assert.Equal(t, defaultPropagators, m.propagators)
+		assert.Nil(t, m.otlpEnableHTTP)
+		assert.Nil(t, m.otlpEnableGRPC)
+		assert.Nil(t, m.zipkinEnable)
+		assert.Equal(t, defaultOTLPHTTPEndpoint, m.otlpEndpointHTTP)
+		assert.Equal(t, defaultOTLPGRPCEndpoint, m.otlpEndpointGRPC)
+		assert.Equal(t, defaultZipkinEndpoint, m.zipkinEndpoint)
+		assert.Nil(t, m.sampler.root)
+	})
+
+	t.Run("environment provides defaults", func(t *testing.T) {
+		t.Setenv("OTEL_SERVICE_NAME", "shop")
+		t.Setenv("OTEL_PROPAGATORS", "baggage")
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318/")
+		t.Setenv("OTEL_EXPORTER_ZIPKIN_ENDPOINT", "http://zipkin:9411/api/v2/spans")
+		t.Setenv("OTEL_TRACES_SAMPLER", "parentbased_traceidratio")
+		t.Setenv("OTEL_TRACES_SAMPLER_ARG", "0.25")
+		t.Setenv("OTEL_EXPORTER_OTLP_CERTIFICATE", "/etc/otel/ca.pem")
+		t.Setenv("OTEL_EXPORTER_OTLP_TRACES_CLIENT_CERTIFICATE", "/etc/otel/client.pem")
+		t.Setenv("OTEL_EXPORTER_OTLP_CLIENT_KEY", "/etc/otel/client.key")
+
+		// the values of the CUE defaults count as not configured
+		m := &Module{
+			sampler:          new(configuredURLPrefixSampler),
+			serviceName:      defaultServiceName,
+			propagators:      defaultPropagators,
+			otlpEndpointHTTP: defaultOTLPHTTPEndpoint,
+			zipkinEndpoint:   defaultZipkinEndpoint,
+		}
+
+		require.NoError(t, m.applyEnvironment(os.LookupEnv))
+
+		assert.Equal(t, "shop", m.serviceName)
+		assert.Equal(t, []string{"baggage"}, m.propagators)
+		assert.Equal(t, "OTEL_PROPAGATORS", m.propagatorsSource)
+		assert.True(t, isEnabled(m.otlpEnableHTTP))
+		assert.Equal(t, "http://collector:4318/v1/traces", m.otlpEndpointHTTP)
+		assert.Nil(t, m.zipkinEnable, "zipkin is only enabled by OTEL_TRACES_EXPORTER")
+		assert.Equal(t, "http://zipkin:9411/api/v2/spans", m.zipkinEndpoint)
+		assert.Equal(t, tracesdk.ParentBased(tracesdk.TraceIDRatioBased(0.25)).Description(), m.sampler.root.Description())
+		assert.Equal(t, transportConfig{
+			Certificate:       "/etc/otel/ca.pem",
+			ClientCertificate: "/etc/otel/client.pem",
+			ClientKey:         "/etc/otel/client.key",
+		}, m.exporterTransport)
+	})
+
+	t.Run("flamingo configuration takes precedence", func(t *testing.T) {
+		t.Setenv("OTEL_SERVICE_NAME", "shop")
+		t.Setenv("OTEL_PROPAGATORS", "baggage")
+		t.Setenv("OTEL_TRACES_EXPORTER", "otlp,zipkin")
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "grpc")
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4317")
+		t.Setenv("OTEL_EXPORTER_OTLP_CERTIFICATE", "/etc/otel/ca.pem")
+
+		disabled := false
+
+		m := &Module{
+			sampler:           new(configuredURLPrefixSampler),
+			serviceName:       "checkout",
+			propagators:       []string{"tracecontext"},
+			otlpEndpointGRPC:  "otel:4317",
+			zipkinEnable:      &disabled,
+			exporterTransport: transportConfig{Certificate: "/etc/ssl/collector.pem"},
+		}
+
+		require.NoError(t, m.applyEnvironment(os.LookupEnv))
+
+		assert.Equal(t, "checkout", m.serviceName)
+		assert.Equal(t, []string{"tracecontext"}, m.propagators)
+		assert.Empty(t, m.propagatorsSource, "the propagators of the flamingo configuration are reported with its key")
+		assert.True(t, isEnabled(m.otlpEnableGRPC))
+		assert.Nil(t, m.otlpEnableHTTP)
+		assert.Equal(t, "otel:4317", m.otlpEndpointGRPC)
+		assert.False(t, isEnabled(m.zipkinEnable), "a disabled exporter stays disabled")
+		assert.Equal(t, "/etc/ssl/collector.pem", m.exporterTransport.Certificate)
+	})
+
+	t.Run("empty propagator list disables the propagation", func(t *testing.T) {
+		t.Setenv("OTEL_PROPAGATORS", "baggage")
+
+		m := &Module{sampler: new(configuredURLPrefixSampler), propagators: []string{}}
+
+		require.NoError(t, m.applyEnvironment(os.LookupEnv))
+
+		assert.NotNil(t, m.propagators)
+		assert.Empty(t, m.propagators)
+	})
+
+	t.Run("OTLP over HTTP with JSON", func(t *testing.T) {
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/json")
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+
+		m := &Module{sampler: new(configuredURLPrefixSampler), otlpHTTPOptions: exporterConfig{Encoding: encodingProtobuf}}
+
+		require.NoError(t, m.applyEnvironment(os.LookupEnv))
+
+		assert.True(t, isEnabled(m.otlpEnableHTTP))
+		assert.Equal(t, encodingJSON, m.otlpHTTPOptions.Encoding)
+		assert.Equal(t, "http://collector:4318/v1/traces", m.otlpEndpointHTTP)
+	})
+
+	t.Run("none disables exporters and propagators", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_EXPORTER", "none")
+		t.Setenv("OTEL_PROPAGATORS", "none")
+		t.Setenv("OTEL_EXPORTER_OTLP_ENDPOINT", "http://collector:4318")
+
+		m := &Module{sampler: new(configuredURLPrefixSampler)}
+
+		require.NoError(t, m.applyEnvironment(os.LookupEnv))
+
+		assert.Nil(t, m.otlpEnableHTTP)
+		assert.Empty(t, m.propagators)
+	})
+
+	t.Run("invalid values are reported", func(t *testing.T) {
+		t.Setenv("OTEL_TRACES_EXPORTER", "otlp")
+		t.Setenv("OTEL_EXPORTER_OTLP_PROTOCOL", "http/xml")
+		t.Setenv("OTEL_TRACES_SAMPLER", "jaeger_remote")
+
+		m := &Module{sampler: new(configuredURLPrefixSampler)}
+
+		err := m.applyEnvironment(os.LookupEnv)
+
+		require.ErrorIs(t, err, errUnsupportedOTLP)
+		require.ErrorIs(t, err, errUnsupportedSampler)
+		assert.Nil(t, m.sampler.root)
+	})
+}
+
+func TestSamplerFromEnv(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		arg     string
+		want    tracesdk.Sampler
+		wantErr error
+	}{
+		{name: "always_on", want: tracesdk.AlwaysSample()},
+		{name: "always_off", want: tracesdk.NeverSample()},
+		{name: "traceidratio", want: tracesdk.TraceIDRatioBased(1)},
+		{name: "traceidratio", arg: "0.5", want: tracesdk.TraceIDRatioBased(0.5)},
+		{name: "traceidratio", arg: "2", wantErr: errInvalidSamplerArg},
+		{name: "parentbased_always_on", want: tracesdk.ParentBased(tracesdk.AlwaysSample())},
+		{name: "parentbased_always_off", want: tracesdk.ParentBased(tracesdk.NeverSample())},
+		{name: "parentbased_traceidratio", arg: "0.1", want: tracesdk.ParentBased(tracesdk.TraceIDRatioBased(0.1))},
+		{name: "xray", wantErr: errUnsupportedSampler},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name+tt.arg, func(t *testing.T) {
+			t.Parallel()
+
+			got, err := samplerFromEnv(tt.name, tt.arg)
+			if tt.wantErr != nil {
+				assert.ErrorIs(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+			assert.Equal(t, tt.want.Description(), got.Description())
+		})
+	}
+}
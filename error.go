@@ -1,24 +1,187 @@
 package opentelemetry
 
 import (
+	"context"
+	"errors"
+	"maps"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+
 	"flamingo.me/flamingo/v3/framework/flamingo"
 )
 
+const (
+	errorCategoryInternal = "internal"
+	errorCategoryExporter = "exporter"
+	errorCategoryMetrics  = "metric collection"
+	errorCategoryBridge   = "bridge"
+	errorCategorySampler  = "sampler"
+)
+
 type (
 	errorHandler struct {
-		logger flamingo.Logger
+		logger    flamingo.Logger
+		metrics   atomic.Pointer[pipelineMetrics]
+		limit     int
+		interval  time.Duration
+		afterFunc func(d time.Duration, f func())
+
+		mu      sync.Mutex
+		windows map[errorKey]*errorWindow
+	}
+
+	// errorKey groups identical errors
+	errorKey struct {
+		category string
+		message  string
+	}
+
+	// errorWindow counts the occurrences of an error within one rate limiting interval
+	errorWindow struct {
+		logged     int
+		suppressed int
+	}
+
+	// exporterError marks errors returned by an exporter of the module
+	exporterError struct {
+		exporter string
+		err      error
+	}
+
+	// categorizedError marks errors of the module's other components with their category
+	categorizedError struct {
+		category string
+		err      error
 	}
 )
 
-func newErrorHandler(logger flamingo.Logger) *errorHandler {
+func newErrorHandler(logger flamingo.Logger, limit int, interval time.Duration) *errorHandler {
 	return &errorHandler{
-		logger: logger,
+		logger:   logger,
+		limit:    limit,
+		interval: interval,
+		afterFunc: func(d time.Duration, f func()) {
+			time.AfterFunc(d, f)
+		},
+		windows: make(map[errorKey]*errorWindow),
 	}
 }
 
+// setMetrics enables counting of handled errors once the module's meter provider is available
+func (e *errorHandler) setMetrics(metrics *pipelineMetrics) {
+	e.metrics.Store(metrics)
+}
+
+// Handle logs the error unless the same error has already been logged limit times within the current interval
 func (e *errorHandler) Handle(err error) {
-	e.logger.
+	category := classifyError(err)
+	e.metrics.Load().recordError(category)
+
+	if e.limit <= 0 || e.interval <= 0 {
+		e.log(category).Error(err)
+
+		return
+	}
+
+	key := errorKey{category: category, message: err.Error()}
+
+	e.mu.Lock()
+
+	window, ok := e.windows[key]
+	if !ok {
+		window = new(errorWindow)
+		e.windows[key] = window
+		e.afterFunc(e.interval, func() { e.closeWindow(key, window) })
+	}
+
+	suppress := window.logged >= e.limit
+	if suppress {
+		window.suppressed++
+	} else {
+		window.logged++
+	}
+
+	e.mu.Unlock()
+
+	if !suppress {
+		e.log(category).Error(err)
+	}
+}
+
+// closeWindow ends the rate limiting interval of an error and logs a summary of the suppressed occurrences, a timer
+// of a window which was already closed by closeWindows does nothing, even if a new window was opened for the error
+func (e *errorHandler) closeWindow(key errorKey, window *errorWindow) {
+	e.mu.Lock()
+
+	if e.windows[key] != window {
+		e.mu.Unlock()
+
+		return
+	}
+
+	delete(e.windows, key)
+	e.mu.Unlock()
+
+	if window.suppressed == 0 {
+		return
+	}
+
+	e.log(key.category).Errorf("suppressed %d more occurrences within %s of: %s", window.suppressed, e.interval, key.message)
+}
+
+// closeWindows ends all rate limiting intervals on shutdown, so that the summaries of suppressed errors are not lost
+func (e *errorHandler) closeWindows(context.Context) error {
+	e.mu.Lock()
+	windows := maps.Clone(e.windows)
+	e.mu.Unlock()
+
+	for key, window := range windows {
+		e.closeWindow(key, window)
+	}
+
+	return nil
+}
+
+func (e *errorHandler) log(category string) flamingo.Logger {
+	return e.logger.
 		WithField(flamingo.LogKeyModule, "opentelemetry").
-		WithField(flamingo.LogKeyCategory, "internal").
-		Error(err)
+		WithField(flamingo.LogKeyCategory, category)
+}
+
+// classifyError returns the category of errors marked by the module or known from the SDK, all others are internal
+func classifyError(err error) string {
+	var (
+		exporterErr    *exporterError
+		categorizedErr *categorizedError
+	)
+
+	switch {
+	case errors.As(err, &exporterErr):
+		return errorCategoryExporter
+	case errors.As(err, &categorizedErr):
+		return categorizedErr.category
+	case errors.Is(err, sdkMetric.ErrReaderShutdown), errors.Is(err, sdkMetric.ErrReaderNotRegistered):
+		return errorCategoryMetrics
+	}
+
+	return errorCategoryInternal
+}
+
+func (e *exporterError) Error() string {
+	return e.exporter + " exporter failed: " + e.err.Error()
+}
+
+func (e *exporterError) Unwrap() error {
+	return e.err
+}
+
+func (e *categorizedError) Error() string {
+	return e.err.Error()
+}
+
+func (e *categorizedError) Unwrap() error {
+	return e.err
 }
@@ -0,0 +1,269 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private error handler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+type (
+	recordingLogger struct {
+		flamingo.NullLogger
+		mu       *sync.Mutex
+		fields   map[flamingo.LogKey]any
+		messages *[]string
+		entries  *[]map[flamingo.LogKey]any
+	}
+)
+
+// ignoreErrors is the error handler of components whose errors are not part of the test
+var ignoreErrors = otel.ErrorHandlerFunc(func(error) {})
+
+func newRecordingLogger() *recordingLogger {
+	return &recordingLogger{
+		mu:       new(sync.Mutex),
+		fields:   map[flamingo.LogKey]any{},
+		messages: new([]string),
+		entries:  new([]map[flamingo.LogKey]any),
+	}
+}
+
+func (l *recordingLogger) WithField(key flamingo.LogKey, value any) flamingo.Logger {
+	fields := make(map[flamingo.LogKey]any, len(l.fields)+1)
+	for k, v := range l.fields {
+		fields[k] = v
+	}
+
+	fields[key] = value
+
+	return &recordingLogger{mu: l.mu, fields: fields, messages: l.messages, entries: l.entries}
+}
+
+func (l *recordingLogger) Error(args ...any) {
+	l.record(fmt.Sprint(args...))
+}
+
+func (l *recordingLogger) Errorf(format string, args ...any) {
+	l.record(fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Warn(args ...any) {
+	l.record(fmt.Sprint(args...))
+}
+
+func (l *recordingLogger) Warnf(format string, args ...any) {
+	l.record(fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) Info(args ...any) {
+	l.record(fmt.Sprint(args...))
+}
+
+func (l *recordingLogger) Infof(format string, args ...any) {
+	l.record(fmt.Sprintf(format, args...))
+}
+
+func (l *recordingLogger) record(message string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	*l.messages = append(*l.messages, message)
+	*l.entries = append(*l.entries, l.fields)
+}
+
+func (l *recordingLogger) Messages() []string {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]string(nil), *l.messages...)
+}
+
+func (l *recordingLogger) Entries() []map[flamingo.LogKey]any {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	return append([]map[flamingo.LogKey]any(nil), *l.entries...)
+}
+
+func TestErrorHandler_Handle(t *testing.T) {
+	t.Parallel()
+
+	t.Run("log every error if rate limiting is disabled", func(t *testing.T) {
+		t.Parallel()
+
+		logger := newRecordingLogger()
+		handler := newErrorHandler(logger, 0, time.Minute)
+
+		for range 5 {
+			handler.Handle(errExport)
+		}
+
+		assert.Len(t, logger.Messages(), 5)
+	})
+
+	t.Run("suppress identical errors above the limit and summarize them", func(t *testing.T) {
+		t.Parallel()
+
+		logger := newRecordingLogger()
+		handler := newErrorHandler(logger, 2, time.Minute)
+
+		var closeWindows []func()
+
+		handler.afterFunc = func(d time.Duration, f func()) {
+			assert.Equal(t, time.Minute, d)
+
+			closeWindows = append(closeWindows, f)
+		}
+
+		otherErr := errors.New("other error")
+
+		for range 5 {
+			handler.Handle(errExport)
+		}
+
+		handler.Handle(otherErr)
+
+		assert.Equal(t, []string{"export error", "export error", "other error"}, logger.Messages())
+		assert.Len(t, closeWindows, 2)
+
+		for _, f := range closeWindows {
+			f()
+		}
+
+		assert.Equal(t, []string{
+			"export error",
+			"export error",
+			"other error",
+			"suppressed 3 more occurrences within 1m0s of: export error",
+		}, logger.Messages())
+
+		handler.Handle(errExport)
+
+		assert.Len(t, logger.Messages(), 5, "a new interval should log the error again")
+	})
+
+	t.Run("timers of windows closed on shutdown do nothing", func(t *testing.T) {
+		t.Parallel()
+
+		logger := newRecordingLogger()
+		handler := newErrorHandler(logger, 1, time.Minute)
+
+		var closeWindows []func()
+
+		handler.afterFunc = func(_ time.Duration, f func()) {
+			closeWindows = append(closeWindows, f)
+		}
+
+		handler.Handle(errExport)
+		handler.Handle(errExport)
+		require.NoError(t, handler.closeWindows(context.Background()))
+
+		handler.Handle(errExport)
+		handler.Handle(errExport)
+
+		// the timer of the window closed on shutdown fires late
+		closeWindows[0]()
+
+		assert.Equal(t, []string{
+			"export error",
+			"suppressed 1 more occurrences within 1m0s of: export error",
+			"export error",
+		}, logger.Messages())
+
+		closeWindows[1]()
+
+		assert.Len(t, logger.Messages(), 4, "the current window is closed by its own timer")
+	})
+}
+
+func TestClassifyError(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name string
+		err  error
+		want string
+	}{
+		{
+			name: "exporter",
+			err:  fmt.Errorf("batch failed: %w", &exporterError{exporter: "otlp.http", err: errExport}),
+			want: errorCategoryExporter,
+		},
+		{
+			name: "categorized",
+			err:  fmt.Errorf("command: %w", &categorizedError{category: errorCategoryMetrics, err: errExport}),
+			want: errorCategoryMetrics,
+		},
+		{
+			name: "bridge",
+			err:  errors.Join(errExport, &categorizedError{category: errorCategoryBridge, err: errExport}),
+			want: errorCategoryBridge,
+		},
+		{
+			name: "sampler",
+			err: func() error {
+				_, err := samplerFromEnv("xray", "")
+
+				return fmt.Errorf("failed to configure: %w", err)
+			}(),
+			want: errorCategorySampler,
+		},
+		{
+			name: "command sampler",
+			err: func() error {
+				_, err := newCommandSampler(tracesdk.AlwaysSample(), commandConfig{SampleRatio: 2}, nil)
+
+				return err
+			}(),
+			want: errorCategorySampler,
+		},
+		{
+			name: "sampler list",
+			err:  (&configuredURLPrefixSampler{allowlist: []string{"checkout"}}).validate(),
+			want: errorCategorySampler,
+		},
+		{
+			name: "metric reader",
+			err:  fmt.Errorf("collect: %w", sdkMetric.ErrReaderShutdown),
+			want: errorCategoryMetrics,
+		},
+		{
+			name: "message is not classified",
+			err:  errors.New("failed to export metric collection"),
+			want: errorCategoryInternal,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			assert.Equal(t, tt.want, classifyError(tt.err))
+		})
+	}
+}
+
+func TestErrorHandler_Category(t *testing.T) {
+	t.Parallel()
+
+	logger := newRecordingLogger()
+	handler := newErrorHandler(logger, 0, 0)
+
+	handler.Handle(&exporterError{exporter: "zipkin", err: errExport})
+
+	entries := logger.Entries()
+	if assert.Len(t, entries, 1) {
+		assert.Equal(t, errorCategoryExporter, entries[0][flamingo.LogKeyCategory])
+	}
+}
@@ -0,0 +1,71 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+var (
+	eventTypeKey  = attribute.Key("flamingo.event.type")
+	subscriberKey = attribute.Key("flamingo.event.subscriber")
+)
+
+type (
+	// eventConfig enables the event notification spans
+	eventConfig struct {
+		Enable bool `json:"enable"`
+	}
+
+	// tracingEventRouter dispatches events like flamingo's default router and records a span for every notification
+	// of a subscriber, events outside of a trace, e.g. the startup events, are dispatched without spans
+	tracingEventRouter struct {
+		subscribers func() []flamingo.Subscriber
+		tracer      trace.Tracer
+	}
+)
+
+var _ flamingo.EventRouter = (*tracingEventRouter)(nil)
+
+// Inject dependencies
+func (r *tracingEventRouter) Inject(subscribers func() []flamingo.Subscriber, provider trace.TracerProvider) *tracingEventRouter {
+	r.subscribers = subscribers
+	r.tracer = provider.Tracer(tracerName)
+
+	return r
+}
+
+func (r *tracingEventRouter) Dispatch(ctx context.Context, event flamingo.Event) {
+	if r.subscribers == nil {
+		return
+	}
+
+	traced := trace.SpanFromContext(ctx).IsRecording()
+	eventType := fmt.Sprintf("%T", event)
+
+	for _, subscriber := range r.subscribers() {
+		if !traced {
+			subscriber.Notify(ctx, event)
+
+			continue
+		}
+
+		r.notify(ctx, subscriber, event, eventType)
+	}
+}
+
+func (r *tracingEventRouter) notify(ctx context.Context, subscriber flamingo.Subscriber, event flamingo.Event, eventType string) {
+	subscriberType := fmt.Sprintf("%T", subscriber)
+
+	ctx, span := r.tracer.Start(ctx, "notify "+subscriberType, trace.WithAttributes(
+		eventTypeKey.String(eventType),
+		subscriberKey.String(subscriberType),
+	))
+	defer span.End()
+
+	subscriber.Notify(ctx, event)
+}
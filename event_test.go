@@ -0,0 +1,60 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private event router
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+type (
+	testCartEvent struct{}
+
+	// recordingSubscriber remembers the span context of every notification
+	recordingSubscriber struct {
+		notified []trace.SpanContext
+	}
+)
+
+func (s *recordingSubscriber) Notify(ctx context.Context, _ flamingo.Event) {
+	s.notified = append(s.notified, trace.SpanContextFromContext(ctx))
+}
+
+func TestTracingEventRouter(t *testing.T) {
+	t.Parallel()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exp))
+	subscriber := new(recordingSubscriber)
+
+	router := new(tracingEventRouter).Inject(func() []flamingo.Subscriber { return []flamingo.Subscriber{subscriber} }, tp)
+
+	router.Dispatch(context.Background(), &flamingo.StartupEvent{})
+	assert.Empty(t, exp.GetSpans(), "no spans outside of a trace")
+
+	ctx, request := tp.Tracer("test").Start(context.Background(), "request")
+	router.Dispatch(ctx, &testCartEvent{})
+	request.End()
+
+	spans := exp.GetSpans()
+	require.Len(t, spans, 2)
+
+	notify := spans[0]
+	assert.Equal(t, "notify *opentelemetry.recordingSubscriber", notify.Name)
+	assert.Equal(t, request.SpanContext().SpanID(), notify.Parent.SpanID())
+	assert.ElementsMatch(t, []attribute.KeyValue{
+		eventTypeKey.String("*opentelemetry.testCartEvent"),
+		subscriberKey.String("*opentelemetry.recordingSubscriber"),
+	}, notify.Attributes)
+
+	require.Len(t, subscriber.notified, 2)
+	assert.False(t, subscriber.notified[0].IsValid())
+	assert.Equal(t, notify.SpanContext, subscriber.notified[1], "the subscriber is notified inside the span")
+}
@@ -0,0 +1,489 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+)
+
+const (
+	protocolOTLPHTTP = "otlp.http"
+	protocolOTLPGRPC = "otlp.grpc"
+	protocolZipkin   = "zipkin"
+
+	processorBatch  = "batch"
+	processorSimple = "simple"
+
+	encodingProtobuf = "protobuf"
+	encodingJSON     = "json"
+
+	compressionNone = "none"
+	compressionGzip = "gzip"
+)
+
+var (
+	errInvalidExporter = errors.New("invalid exporter")
+)
+
+type (
+	// exporterConfig describes one trace exporter from flamingo.opentelemetry.exporters or the single exporter settings
+	exporterConfig struct {
+		Name           string               `json:"name"`
+		Protocol       string               `json:"protocol"`
+		Endpoint       string               `json:"endpoint"`
+		Headers        map[string]string    `json:"headers"`
+		Filter         spanFilter           `json:"filter"`
+		Encoding       string               `json:"encoding"`
+		Compression    string               `json:"compression"`
+		Timeout        string               `json:"timeout"`
+		Retry          retryConfig          `json:"retry"`
+		Spool          spoolConfig          `json:"spool"`
+		CircuitBreaker circuitBreakerConfig `json:"circuitBreaker"`
+		processorConfig
+
+		// configKey is used to point to the configuration in error messages
+		configKey string
+		// transport is shared by the exporters, the gRPC exporter only uses its dialer and proxy
+		transport *http.Transport
+		// output and pretty configure the stdout exporter
+		output io.Writer
+		pretty bool
+	}
+
+	// processorConfig selects the span processor of an exporter, the batch processor is used by default
+	processorConfig struct {
+		Processor string      `json:"processor"`
+		Batch     batchConfig `json:"batch"`
+	}
+
+	// batchConfig holds the batch span processor settings, zero values keep the SDK defaults
+	batchConfig struct {
+		MaxQueueSize       int    `json:"maxQueueSize"`
+		MaxExportBatchSize int    `json:"maxExportBatchSize"`
+		ScheduleDelay      string `json:"scheduleDelay"`
+		ExportTimeout      string `json:"exportTimeout"`
+	}
+
+	// spanFilter selects the spans sent to an exporter, an empty filter selects all spans
+	spanFilter struct {
+		// Attributes must all be present on the span with the given value
+		Attributes map[string]string `json:"attributes"`
+		// Scopes contains the accepted instrumentation scope names
+		Scopes []string `json:"scopes"`
+		// Services contains the accepted service names
+		Services []string `json:"services"`
+	}
+
+	// filteringSpanProcessor only passes the ended spans matching the filter to the next processor
+	filteringSpanProcessor struct {
+		tracesdk.SpanProcessor
+		filter spanFilter
+	}
+)
+
+var _ tracesdk.SpanProcessor = (*filteringSpanProcessor)(nil)
+
+// exporterConfigs returns the enabled single exporters followed by the named exporters
+func (m *Module) exporterConfigs() []exporterConfig {
+	configs := make([]exporterConfig, 0, len(m.exporters)+4) //nolint:mnd // the single and the stdout exporters
+
+	// the single exporters take their remaining settings, e.g. the processor, from their configuration block
+	single := func(options exporterConfig, protocol, endpoint string) exporterConfig {
+		options.Name = protocol
+		options.Protocol = protocol
+		options.Endpoint = endpoint
+		options.configKey = "flamingo.opentelemetry." + protocol
+
+		return options
+	}
+
+	if isEnabled(m.otlpEnableHTTP) {
+		configs = append(configs, single(m.otlpHTTPOptions, protocolOTLPHTTP, m.otlpEndpointHTTP))
+	}
+
+	if isEnabled(m.otlpEnableGRPC) {
+		configs = append(configs, single(m.otlpGRPCOptions, protocolOTLPGRPC, m.otlpEndpointGRPC))
+	}
+
+	if isEnabled(m.zipkinEnable) {
+		configs = append(configs, single(m.zipkinOptions, protocolZipkin, m.zipkinEndpoint))
+	}
+
+	// the stdout exporter is left out if its output could not be opened, which is already reported
+	if m.stdout.Traces && m.stdoutOutput != nil {
+		configs = append(configs, exporterConfig{
+			Name:            protocolStdout,
+			Protocol:        protocolStdout,
+			Endpoint:        m.stdout.Path,
+			processorConfig: m.stdout.processorConfig,
+			configKey:       "flamingo.opentelemetry.stdout",
+			output:          m.stdoutOutput,
+			pretty:          m.stdout.Format == stdoutFormatPretty,
+		})
+	}
+
+	for i, cfg := range m.exporters {
+		cfg.configKey = fmt.Sprintf("flamingo.opentelemetry.exporters[%d]", i)
+		configs = append(configs, cfg)
+	}
+
+	return configs
+}
+
+// initExporters creates a span processor for every exporter, exporters which could not be created are reported and left out
+func (m *Module) initExporters() ([]tracesdk.SpanProcessor, error) {
+	var errs []error
+
+	// without a valid transport no exporter is created, instead of sending the telemetry past the configured proxy
+	transport, transportErr := m.exporterTransport.transport()
+	if transportErr != nil {
+		errs = append(errs, transportErr)
+	}
+
+	configs := m.exporterConfigs()
+	processors := make([]tracesdk.SpanProcessor, 0, len(configs))
+	names := make(map[string]bool, len(configs))
+
+	for _, cfg := range configs {
+		if cfg.Name == "" || names[cfg.Name] {
+			errs = append(errs, fmt.Errorf("%w %s: name %q must be set and unique", errInvalidExporter, cfg.configKey, cfg.Name))
+
+			continue
+		}
+
+		names[cfg.Name] = true
+
+		if transportErr != nil {
+			continue
+		}
+
+		cfg.transport = transport
+
+		newProcessor, err := cfg.processorFactory()
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		exp, err := newSpanExporter(cfg)
+		if err != nil {
+			errs = append(errs, err)
+
+			continue
+		}
+
+		if cfg.CircuitBreaker.FailureThreshold != 0 {
+			breaker, err := newCircuitBreaker(cfg, m.logger)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
+			}
+
+			exp = &breakingSpanExporter{next: exp, breaker: breaker, metrics: m.metrics, spooled: cfg.Spool.Directory != ""}
+		}
+
+		if cfg.Spool.Directory != "" {
+			spool, err := newSpoolingExporter(cfg, exp, m.metrics, m.errorHandler)
+			if err != nil {
+				errs = append(errs, err)
+
+				continue
+			}
+
+			// the replay is stopped by the shutdown of the tracer provider, or here if the module fails to start
+			m.register(component{name: "spool replay of " + cfg.Name, close: spool.stop})
+
+			exp = spool
+		}
+
+		if cfg.Protocol == protocolZipkin {
+			m.log().Warnf("exporter %s: the Zipkin protocol is deprecated in OpenTelemetry and kept for existing Zipkin backends, "+
+				"consider sending OTLP to a collector which forwards to Zipkin instead", cfg.Name)
+		}
+
+		processors = append(processors, cfg.Filter.wrap(m.metrics.observe(cfg.Name, exp, newProcessor, cfg.queueSize())))
+	}
+
+	return processors, errors.Join(errs...)
+}
+
+func newSpanExporter(cfg exporterConfig) (tracesdk.SpanExporter, error) {
+	if err := cfg.validateWireFormat(); err != nil {
+		return nil, err
+	}
+
+	switch cfg.Protocol {
+	case protocolOTLPHTTP:
+		return newOTLPHTTPExporter(cfg)
+	case protocolOTLPGRPC:
+		return newOTLPGRPCExporter(cfg)
+	case protocolZipkin:
+		return newZipkinExporter(cfg)
+	case protocolStdout:
+		return newStdoutExporter(cfg)
+	}
+
+	return nil, fmt.Errorf("%w %s: unknown protocol %q", errInvalidExporter, cfg.configKey, cfg.Protocol)
+}
+
+// Create the OTLP HTTP exporter
+func newOTLPHTTPExporter(cfg exporterConfig) (tracesdk.SpanExporter, error) {
+	u, err := parseEndpointURL(cfg.configKey+".endpoint", cfg.Endpoint)
+	if err != nil {
+		return nil, err
+	}
+
+	policy, err := cfg.exportPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := append([]otlptracehttp.Option{
+		otlptracehttp.WithEndpoint(u.Host),
+		otlptracehttp.WithURLPath(u.Path),
+	}, policy.httpOptions()...)
+	if u.Scheme == "http" {
+		opts = append(opts, otlptracehttp.WithInsecure())
+	}
+
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracehttp.WithHeaders(cfg.Headers))
+	}
+
+	if cfg.Compression == compressionGzip {
+		opts = append(opts, otlptracehttp.WithCompression(otlptracehttp.GzipCompression))
+	}
+
+	if cfg.Encoding == encodingJSON {
+		exp, err := otlptrace.New(context.Background(), newOTLPJSONClient(cfg, u, policy))
+		if err != nil {
+			return nil, fmt.Errorf("failed to initialize OTLP HTTP exporter %s: %w", cfg.Name, err)
+		}
+
+		return exp, nil
+	}
+
+	timeout := policy.timeout
+	if timeout == 0 {
+		timeout = defaultExportTimeout
+	}
+
+	// the own client keeps the exporter away from the instrumented http.DefaultTransport and the proxy environment
+	opts = append(opts, otlptracehttp.WithHTTPClient(cfg.httpClient(timeout)))
+
+	exp, err := otlptracehttp.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize OTLP HTTP exporter %s: %w", cfg.Name, err)
+	}
+
+	return exp, nil
+}
+
+// Create the OTLP gRPC exporter
+func newOTLPGRPCExporter(cfg exporterConfig) (tracesdk.SpanExporter, error) {
+	if err := validateGRPCEndpoint(cfg.configKey+".endpoint", cfg.Endpoint); err != nil {
+		return nil, err
+	}
+
+	policy, err := cfg.exportPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	opts := []otlptracegrpc.Option{
+		otlptracegrpc.WithEndpoint(cfg.Endpoint),
+	}
+
+	insecure := false
+
+	// endpoints given as URL, e.g. from OTEL_EXPORTER_OTLP_ENDPOINT, are reduced to their host
+	if strings.Contains(cfg.Endpoint, "://") {
+		u, _ := url.Parse(cfg.Endpoint) // already validated
+
+		opts = []otlptracegrpc.Option{otlptracegrpc.WithEndpoint(u.Host)}
+		if u.Scheme == "http" {
+			insecure = true
+			opts = append(opts, otlptracegrpc.WithInsecure())
+		}
+	}
+
+	// the collector is dialed like the HTTP exporters do, ignoring the proxy environment variables
+	transport := cfg.exporterTransport()
+	dialer := dialGRPC(transport)
+	opts = append(opts, otlptracegrpc.WithDialOption(grpc.WithNoProxy(), grpc.WithContextDialer(dialer)))
+
+	if transport.TLSClientConfig != nil && !insecure {
+		opts = append(opts, otlptracegrpc.WithTLSCredentials(credentials.NewTLS(transport.TLSClientConfig.Clone())))
+	}
+	opts = append(opts, policy.grpcOptions()...)
+
+	if len(cfg.Headers) > 0 {
+		opts = append(opts, otlptracegrpc.WithHeaders(cfg.Headers))
+	}
+
+	if cfg.Compression == compressionGzip {
+		opts = append(opts, otlptracegrpc.WithCompressor(compressionGzip))
+	}
+
+	exp, err := otlptracegrpc.New(context.Background(), opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize OTLP gRPC exporter %s: %w", cfg.Name, err)
+	}
+
+	return exp, nil
+}
+
+// validateWireFormat checks the encoding and compression, which are only supported by the OTLP exporters
+func (cfg exporterConfig) validateWireFormat() error {
+	otlp := cfg.Protocol == protocolOTLPHTTP || cfg.Protocol == protocolOTLPGRPC
+
+	switch {
+	case cfg.Encoding != "" && cfg.Encoding != encodingProtobuf && cfg.Encoding != encodingJSON:
+		return fmt.Errorf("%w %s.encoding: unknown encoding %q", errInvalidExporter, cfg.configKey, cfg.Encoding)
+	case cfg.Encoding == encodingJSON && cfg.Protocol != protocolOTLPHTTP:
+		return fmt.Errorf("%w %s.encoding: json is only supported by otlp.http", errInvalidExporter, cfg.configKey)
+	case cfg.Compression != "" && cfg.Compression != compressionNone && cfg.Compression != compressionGzip:
+		return fmt.Errorf("%w %s.compression: unknown compression %q", errInvalidExporter, cfg.configKey, cfg.Compression)
+	case cfg.Compression == compressionGzip && !otlp:
+		return fmt.Errorf("%w %s.compression: gzip is only supported by the OTLP exporters", errInvalidExporter, cfg.configKey)
+	}
+
+	return nil
+}
+
+// processorFactory returns the constructor of the configured span processor
+func (cfg exporterConfig) processorFactory() (func(tracesdk.SpanExporter) tracesdk.SpanProcessor, error) {
+	switch cfg.Processor {
+	case processorSimple:
+		return tracesdk.NewSimpleSpanProcessor, nil
+	case "", processorBatch:
+		opts, err := cfg.Batch.options(cfg.configKey + ".batch")
+		if err != nil {
+			return nil, err
+		}
+
+		return func(exp tracesdk.SpanExporter) tracesdk.SpanProcessor {
+			return tracesdk.NewBatchSpanProcessor(exp, opts...)
+		}, nil
+	}
+
+	return nil, fmt.Errorf("%w %s.processor: unknown processor %q", errInvalidExporter, cfg.configKey, cfg.Processor)
+}
+
+// queueSize returns the queue size of the span processor, zero for the simple processor without a queue
+func (cfg exporterConfig) queueSize() int {
+	if cfg.Processor == processorSimple {
+		return 0
+	}
+
+	return cfg.Batch.queueSize()
+}
+
+// queueSize resolves the queue size the same way as the SDK, which falls back to OTEL_BSP_MAX_QUEUE_SIZE
+func (b batchConfig) queueSize() int {
+	if b.MaxQueueSize > 0 {
+		return b.MaxQueueSize
+	}
+
+	if size, err := strconv.Atoi(os.Getenv("OTEL_BSP_MAX_QUEUE_SIZE")); err == nil && size > 0 {
+		return size
+	}
+
+	return tracesdk.DefaultMaxQueueSize
+}
+
+// options converts the batch settings, unset values are left to the SDK which reads the OTEL_BSP_* environment variables
+func (b batchConfig) options(key string) ([]tracesdk.BatchSpanProcessorOption, error) {
+	var (
+		errs []error
+		opts []tracesdk.BatchSpanProcessorOption
+	)
+
+	if b.MaxQueueSize < 0 || b.MaxExportBatchSize < 0 {
+		errs = append(errs, fmt.Errorf("%w %s: queue and batch sizes must not be negative", errInvalidExporter, key))
+	}
+
+	// the queue size is always set, the pipeline metrics rely on it
+	opts = append(opts, tracesdk.WithMaxQueueSize(b.queueSize()))
+
+	if b.MaxExportBatchSize > 0 {
+		opts = append(opts, tracesdk.WithMaxExportBatchSize(b.MaxExportBatchSize))
+	}
+
+	if b.ScheduleDelay != "" {
+		delay, err := time.ParseDuration(b.ScheduleDelay)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w %s.scheduleDelay: %w", errInvalidExporter, key, err))
+		}
+
+		opts = append(opts, tracesdk.WithBatchTimeout(delay))
+	}
+
+	if b.ExportTimeout != "" {
+		timeout, err := time.ParseDuration(b.ExportTimeout)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w %s.exportTimeout: %w", errInvalidExporter, key, err))
+		}
+
+		opts = append(opts, tracesdk.WithExportTimeout(timeout))
+	}
+
+	return opts, errors.Join(errs...)
+}
+
+// wrap returns the processor itself for an empty filter
+func (f spanFilter) wrap(processor tracesdk.SpanProcessor) tracesdk.SpanProcessor {
+	if len(f.Attributes) == 0 && len(f.Scopes) == 0 && len(f.Services) == 0 {
+		return processor
+	}
+
+	return &filteringSpanProcessor{SpanProcessor: processor, filter: f}
+}
+
+func (f spanFilter) matches(span tracesdk.ReadOnlySpan) bool {
+	if len(f.Scopes) > 0 && !slices.Contains(f.Scopes, span.InstrumentationScope().Name) {
+		return false
+	}
+
+	if len(f.Services) > 0 {
+		service, _ := span.Resource().Set().Value(semconv.ServiceNameKey)
+		if !slices.Contains(f.Services, service.AsString()) {
+			return false
+		}
+	}
+
+	for key, want := range f.Attributes {
+		if !slices.ContainsFunc(span.Attributes(), func(kv attribute.KeyValue) bool {
+			return string(kv.Key) == key && kv.Value.Emit() == want
+		}) {
+			return false
+		}
+	}
+
+	return true
+}
+
+func (p *filteringSpanProcessor) OnEnd(span tracesdk.ReadOnlySpan) {
+	if p.filter.matches(span) {
+		p.SpanProcessor.OnEnd(span)
+	}
+}
@@ -0,0 +1,241 @@
+package opentelemetry //nolint:testpackage // explicit testing of private exporter setup
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestSpanFilter(t *testing.T) {
+	t.Parallel()
+
+	type span struct {
+		scope string
+		attrs []attribute.KeyValue
+	}
+
+	tests := []struct {
+		name   string
+		filter spanFilter
+		spans  []span
+		want   []string
+	}{
+		{
+			name: "empty filter passes all spans",
+			spans: []span{
+				{scope: "checkout"},
+				{scope: "search"},
+			},
+			want: []string{"checkout", "search"},
+		},
+		{
+			name:   "filter by scope",
+			filter: spanFilter{Scopes: []string{"checkout", "payment"}},
+			spans: []span{
+				{scope: "checkout"},
+				{scope: "search"},
+				{scope: "payment"},
+			},
+			want: []string{"checkout", "payment"},
+		},
+		{
+			name:   "filter by attributes",
+			filter: spanFilter{Attributes: map[string]string{"area": "checkout", "step": "2"}},
+			spans: []span{
+				{scope: "match", attrs: []attribute.KeyValue{attribute.String("area", "checkout"), attribute.Int("step", 2)}},
+				{scope: "missing", attrs: []attribute.KeyValue{attribute.String("area", "checkout")}},
+				{scope: "different", attrs: []attribute.KeyValue{attribute.String("area", "search"), attribute.Int("step", 2)}},
+			},
+			want: []string{"match"},
+		},
+		{
+			name:   "filter by service",
+			filter: spanFilter{Services: []string{"other"}},
+			spans: []span{
+				{scope: "checkout"},
+			},
+			want: nil,
+		},
+		{
+			name:   "all criteria must match",
+			filter: spanFilter{Scopes: []string{"checkout"}, Services: []string{"shop"}, Attributes: map[string]string{"area": "checkout"}},
+			spans: []span{
+				{scope: "checkout", attrs: []attribute.KeyValue{attribute.String("area", "checkout")}},
+				{scope: "checkout"},
+			},
+			want: []string{"checkout"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exp := tracetest.NewInMemoryExporter()
+			tp := tracesdk.NewTracerProvider(
+				tracesdk.WithResource(resource.NewSchemaless(semconv.ServiceName("shop"))),
+				tracesdk.WithSpanProcessor(tt.filter.wrap(tracesdk.NewSimpleSpanProcessor(exp))),
+			)
+
+			for _, s := range tt.spans {
+				_, span := tp.Tracer(s.scope).Start(context.Background(), s.scope, trace.WithAttributes(s.attrs...))
+				span.End()
+			}
+
+			var got []string
+			for _, s := range exp.GetSpans() {
+				got = append(got, s.Name)
+			}
+
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestModule_InitExporters(t *testing.T) {
+	t.Parallel()
+
+	metrics, _ := newTestPipelineMetrics(t)
+
+	enabled := true
+
+	m := &Module{
+		metrics:          metrics,
+		otlpEnableHTTP:   &enabled,
+		otlpEndpointHTTP: "http://localhost:4318/v1/traces",
+		exporters: []exporterConfig{
+			{Name: "compliance", Protocol: protocolOTLPHTTP, Endpoint: "https://compliance:4318/v1/traces", Filter: spanFilter{Scopes: []string{"checkout"}}},
+			{Name: "otlp.http", Protocol: protocolOTLPHTTP, Endpoint: "http://localhost:4318/v1/traces"},
+			{Name: "zipkin", Protocol: "jaeger", Endpoint: "http://localhost:14268"},
+			{Name: "", Protocol: protocolZipkin, Endpoint: "http://localhost:9411/api/v2/spans"},
+			{Name: "broken", Protocol: protocolZipkin, Endpoint: "localhost:9411"},
+		},
+	}
+
+	processors, err := m.initExporters()
+
+	require.ErrorIs(t, err, errInvalidExporter)
+	require.ErrorIs(t, err, errInvalidEndpoint)
+	assert.ErrorContains(t, err, `flamingo.opentelemetry.exporters[1]: name "otlp.http" must be set and unique`)
+	assert.ErrorContains(t, err, `flamingo.opentelemetry.exporters[2]: unknown protocol "jaeger"`)
+	assert.ErrorContains(t, err, `flamingo.opentelemetry.exporters[3]: name "" must be set and unique`)
+	assert.ErrorContains(t, err, "flamingo.opentelemetry.exporters[4].endpoint")
+	require.Len(t, processors, 2)
+	assert.IsType(t, new(observedSpanProcessor), processors[0])
+	assert.IsType(t, new(filteringSpanProcessor), processors[1])
+
+	for _, p := range processors {
+		require.NoError(t, p.Shutdown(context.Background()))
+	}
+
+	t.Run("invalid transport", func(t *testing.T) {
+		t.Parallel()
+
+		m := &Module{
+			metrics:           metrics,
+			otlpEnableHTTP:    &enabled,
+			otlpEndpointHTTP:  "http://localhost:4318/v1/traces",
+			exporterTransport: transportConfig{Proxy: "proxy"},
+		}
+
+		processors, err := m.initExporters()
+
+		require.ErrorIs(t, err, errInvalidTransport)
+		assert.Empty(t, processors, "no exporter bypasses the configured proxy")
+	})
+}
+
+func TestExporterConfig_ProcessorFactory(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  processorConfig
+		wantErr string
+	}{
+		{name: "batch processor by default"},
+		{name: "batch processor with settings", config: processorConfig{
+			Processor: processorBatch,
+			Batch:     batchConfig{MaxQueueSize: 100, MaxExportBatchSize: 10, ScheduleDelay: "100ms", ExportTimeout: "5s"},
+		}},
+		{name: "simple processor", config: processorConfig{Processor: processorSimple}},
+		{name: "unknown processor", config: processorConfig{Processor: "async"}, wantErr: `test.processor: unknown processor "async"`},
+		{name: "negative sizes", config: processorConfig{Batch: batchConfig{MaxQueueSize: -1}}, wantErr: "test.batch: queue and batch sizes must not be negative"},
+		{name: "invalid durations", config: processorConfig{Batch: batchConfig{ScheduleDelay: "soon", ExportTimeout: "1"}}, wantErr: "test.batch.scheduleDelay"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			newProcessor, err := exporterConfig{processorConfig: tt.config, configKey: "test"}.processorFactory()
+			if tt.wantErr != "" {
+				require.ErrorIs(t, err, errInvalidExporter)
+				assert.ErrorContains(t, err, tt.wantErr)
+
+				return
+			}
+
+			require.NoError(t, err)
+
+			exp := tracetest.NewInMemoryExporter()
+			processor := newProcessor(exp)
+			tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor))
+
+			_, span := tp.Tracer("test").Start(context.Background(), "span")
+			span.End()
+
+			if tt.config.Processor == processorSimple {
+				assert.Len(t, exp.GetSpans(), 1, "the simple processor exports synchronously")
+			}
+
+			require.NoError(t, tp.ForceFlush(context.Background()))
+			assert.Len(t, exp.GetSpans(), 1)
+			require.NoError(t, tp.Shutdown(context.Background()))
+		})
+	}
+}
+
+func TestExporterConfig_ValidateWireFormat(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name    string
+		config  exporterConfig
+		wantErr string
+	}{
+		{name: "defaults", config: exporterConfig{Protocol: protocolZipkin}},
+		{name: "otlp.http json gzip", config: exporterConfig{Protocol: protocolOTLPHTTP, Encoding: encodingJSON, Compression: compressionGzip}},
+		{name: "otlp.grpc gzip", config: exporterConfig{Protocol: protocolOTLPGRPC, Encoding: encodingProtobuf, Compression: compressionGzip}},
+		{name: "unknown encoding", config: exporterConfig{Protocol: protocolOTLPHTTP, Encoding: "xml"}, wantErr: `test.encoding: unknown encoding "xml"`},
+		{name: "json over grpc", config: exporterConfig{Protocol: protocolOTLPGRPC, Encoding: encodingJSON}, wantErr: "test.encoding: json is only supported by otlp.http"},
+		{name: "unknown compression", config: exporterConfig{Protocol: protocolOTLPHTTP, Compression: "zstd"}, wantErr: `test.compression: unknown compression "zstd"`},
+		{name: "gzip for zipkin", config: exporterConfig{Protocol: protocolZipkin, Compression: compressionGzip}, wantErr: "test.compression: gzip is only supported by the OTLP exporters"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			tt.config.configKey = "test"
+
+			err := tt.config.validateWireFormat()
+			if tt.wantErr == "" {
+				assert.NoError(t, err)
+
+				return
+			}
+
+			require.ErrorIs(t, err, errInvalidExporter)
+			assert.ErrorContains(t, err, tt.wantErr)
+		})
+	}
+}
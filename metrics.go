@@ -0,0 +1,342 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	"go.opentelemetry.io/otel/metric/noop"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	meterName = "flamingo.me/opentelemetry"
+
+	samplerRuleAllowlist = "allowlist"
+	samplerRuleBlocklist = "blocklist"
+	samplerRuleDefault   = "default"
+	samplerRuleParent    = "parent"
+	samplerRuleClient    = "client"
+	samplerRuleRoot      = "root"
+	samplerRuleCommand   = "command"
+)
+
+var (
+	exporterKey = attribute.Key("exporter")
+	decisionKey = attribute.Key("decision")
+	ruleKey     = attribute.Key("rule")
+	categoryKey = attribute.Key("category")
+	reasonKey   = attribute.Key("reason")
+)
+
+type (
+	// pipelineMetrics observes the module's own telemetry pipeline
+	pipelineMetrics struct {
+		spansStarted     metric.Int64Counter
+		samplerDecisions metric.Int64Counter
+		spansExported    metric.Int64Counter
+		spansFailed      metric.Int64Counter
+		queueLength      metric.Int64UpDownCounter
+		exportDuration   metric.Float64Histogram
+		errors           metric.Int64Counter
+		spansSpooled     metric.Int64Counter
+		spansReplayed    metric.Int64Counter
+		spansDropped     metric.Int64Counter
+		// totals of the process, reported in the shutdown summary
+		totals struct {
+			exported, failed, dropped, queued atomic.Int64
+		}
+	}
+
+	// pipelineSummary is a snapshot of the span totals
+	pipelineSummary struct {
+		exported, failed, dropped, queued int64
+	}
+
+	// spanCounter counts all spans started by the tracer provider
+	spanCounter struct {
+		metrics *pipelineMetrics
+	}
+
+	// observedSpanProcessor tracks the spans handed to the wrapped processor of an exporter, it drops the spans itself
+	// once the queue is full or the processor is shut down, so that every span is counted as exported, failed or dropped
+	observedSpanProcessor struct {
+		tracesdk.SpanProcessor
+		name    string
+		metrics *pipelineMetrics
+		// queueSize limits the spans waiting for their export, zero for a processor without a queue
+		queueSize int64
+		queued    *atomic.Int64
+		stopped   atomic.Bool
+	}
+
+	// observedExporter records the outcome and latency of every export
+	observedExporter struct {
+		name    string
+		next    tracesdk.SpanExporter
+		metrics *pipelineMetrics
+		queued  *atomic.Int64
+	}
+)
+
+var (
+	_ tracesdk.SpanProcessor = (*spanCounter)(nil)
+	_ tracesdk.SpanProcessor = (*observedSpanProcessor)(nil)
+	_ tracesdk.SpanExporter  = (*observedExporter)(nil)
+)
+
+func newPipelineMetrics(meter metric.Meter) (*pipelineMetrics, error) {
+	var errs [10]error
+
+	p := new(pipelineMetrics)
+
+	p.spansStarted, errs[0] = meter.Int64Counter(
+		"flamingo.opentelemetry.spans.started",
+		metric.WithDescription("Number of recording spans started"),
+		metric.WithUnit("{span}"),
+	)
+	p.samplerDecisions, errs[1] = meter.Int64Counter(
+		"flamingo.opentelemetry.sampler.decisions",
+		metric.WithDescription("Number of sampling decisions by decision and sampler rule"),
+		metric.WithUnit("{span}"),
+	)
+	p.spansExported, errs[2] = meter.Int64Counter(
+		"flamingo.opentelemetry.exporter.spans.exported",
+		metric.WithDescription("Number of spans successfully exported"),
+		metric.WithUnit("{span}"),
+	)
+	p.spansFailed, errs[3] = meter.Int64Counter(
+		"flamingo.opentelemetry.exporter.spans.failed",
+		metric.WithDescription("Number of spans which failed to export"),
+		metric.WithUnit("{span}"),
+	)
+	p.queueLength, errs[4] = meter.Int64UpDownCounter(
+		"flamingo.opentelemetry.processor.queue.length",
+		metric.WithDescription("Number of ended spans waiting in the span processor to be exported"),
+		metric.WithUnit("{span}"),
+	)
+	p.exportDuration, errs[5] = meter.Float64Histogram(
+		"flamingo.opentelemetry.exporter.duration",
+		metric.WithDescription("Duration of span exports"),
+		metric.WithUnit("s"),
+	)
+	p.errors, errs[6] = meter.Int64Counter(
+		"flamingo.opentelemetry.errors",
+		metric.WithDescription("Number of errors reported to the OpenTelemetry error handler"),
+		metric.WithUnit("{error}"),
+	)
+	p.spansSpooled, errs[7] = meter.Int64Counter(
+		"flamingo.opentelemetry.exporter.spans.spooled",
+		metric.WithDescription("Number of spans written to the spool directory after a failed export"),
+		metric.WithUnit("{span}"),
+	)
+	p.spansReplayed, errs[8] = meter.Int64Counter(
+		"flamingo.opentelemetry.exporter.spans.replayed",
+		metric.WithDescription("Number of spooled spans exported after the exporter recovered"),
+		metric.WithUnit("{span}"),
+	)
+	p.spansDropped, errs[9] = meter.Int64Counter(
+		"flamingo.opentelemetry.exporter.spans.dropped",
+		metric.WithDescription("Number of spans dropped by the exporter, e.g. because of the spool limits"),
+		metric.WithUnit("{span}"),
+	)
+
+	err := errors.Join(errs[:]...)
+	if err == nil {
+		return p, nil
+	}
+
+	// the instruments which could not be created record nothing, the others keep working
+	p.spansStarted = usableInstrument(p.spansStarted, errs[0], metric.Int64Counter(noop.Int64Counter{}))
+	p.samplerDecisions = usableInstrument(p.samplerDecisions, errs[1], metric.Int64Counter(noop.Int64Counter{}))
+	p.spansExported = usableInstrument(p.spansExported, errs[2], metric.Int64Counter(noop.Int64Counter{}))
+	p.spansFailed = usableInstrument(p.spansFailed, errs[3], metric.Int64Counter(noop.Int64Counter{}))
+	p.queueLength = usableInstrument(p.queueLength, errs[4], metric.Int64UpDownCounter(noop.Int64UpDownCounter{}))
+	p.exportDuration = usableInstrument(p.exportDuration, errs[5], metric.Float64Histogram(noop.Float64Histogram{}))
+	p.errors = usableInstrument(p.errors, errs[6], metric.Int64Counter(noop.Int64Counter{}))
+	p.spansSpooled = usableInstrument(p.spansSpooled, errs[7], metric.Int64Counter(noop.Int64Counter{}))
+	p.spansReplayed = usableInstrument(p.spansReplayed, errs[8], metric.Int64Counter(noop.Int64Counter{}))
+	p.spansDropped = usableInstrument(p.spansDropped, errs[9], metric.Int64Counter(noop.Int64Counter{}))
+
+	return p, fmt.Errorf("failed to create pipeline metrics: %w", err)
+}
+
+// usableInstrument returns the no-op fallback for an instrument which could not be created
+func usableInstrument[T any](instrument T, err error, fallback T) T {
+	if err != nil {
+		return fallback
+	}
+
+	return instrument
+}
+
+func (p *pipelineMetrics) recordSamplingDecision(ctx context.Context, decision tracesdk.SamplingDecision, rule string) {
+	if p == nil {
+		return
+	}
+
+	p.samplerDecisions.Add(ctx, 1, metric.WithAttributes(
+		decisionKey.String(decisionName(decision)),
+		ruleKey.String(rule),
+	))
+}
+
+func (p *pipelineMetrics) recordSpooled(exporter string, n int64) {
+	if p == nil {
+		return
+	}
+
+	p.spansSpooled.Add(context.Background(), n, metric.WithAttributes(exporterKey.String(exporter)))
+}
+
+func (p *pipelineMetrics) recordReplayed(exporter string, n int64) {
+	if p == nil {
+		return
+	}
+
+	p.spansReplayed.Add(context.Background(), n, metric.WithAttributes(exporterKey.String(exporter)))
+}
+
+func (p *pipelineMetrics) recordDropped(exporter, reason string, n int64) {
+	if p == nil {
+		return
+	}
+
+	p.spansDropped.Add(context.Background(), n, metric.WithAttributes(exporterKey.String(exporter), reasonKey.String(reason)))
+	p.totals.dropped.Add(n)
+}
+
+func (p *pipelineMetrics) summary() pipelineSummary {
+	if p == nil {
+		return pipelineSummary{}
+	}
+
+	return pipelineSummary{
+		exported: p.totals.exported.Load(),
+		failed:   p.totals.failed.Load(),
+		dropped:  p.totals.dropped.Load(),
+		queued:   p.totals.queued.Load(),
+	}
+}
+
+func (p *pipelineMetrics) recordError(category string) {
+	if p == nil {
+		return
+	}
+
+	p.errors.Add(context.Background(), 1, metric.WithAttributes(categoryKey.String(category)))
+}
+
+func decisionName(decision tracesdk.SamplingDecision) string {
+	switch decision {
+	case tracesdk.Drop:
+		return "drop"
+	case tracesdk.RecordOnly:
+		return "record_only"
+	case tracesdk.RecordAndSample:
+		return "record_and_sample"
+	}
+
+	return "unknown"
+}
+
+// observe wraps the exporter and its span processor so that the pipeline metrics are recorded for it, queueSize must
+// not exceed the queue of the processor, so that the processor never drops a span on its own
+func (p *pipelineMetrics) observe(
+	name string,
+	exp tracesdk.SpanExporter,
+	newProcessor func(tracesdk.SpanExporter) tracesdk.SpanProcessor,
+	queueSize int,
+) tracesdk.SpanProcessor {
+	queued := new(atomic.Int64)
+
+	return &observedSpanProcessor{
+		SpanProcessor: newProcessor(&observedExporter{name: name, next: exp, metrics: p, queued: queued}),
+		name:          name,
+		metrics:       p,
+		queueSize:     int64(queueSize),
+		queued:        queued,
+	}
+}
+
+func (s *spanCounter) OnStart(parent context.Context, _ tracesdk.ReadWriteSpan) {
+	s.metrics.spansStarted.Add(parent, 1)
+}
+
+func (*spanCounter) OnEnd(tracesdk.ReadOnlySpan) {}
+
+func (*spanCounter) Shutdown(context.Context) error { return nil }
+
+func (*spanCounter) ForceFlush(context.Context) error { return nil }
+
+func (s *observedSpanProcessor) OnEnd(span tracesdk.ReadOnlySpan) {
+	// the processor ignores spans which are not sampled
+	if !span.SpanContext().IsSampled() {
+		return
+	}
+
+	if s.stopped.Load() {
+		s.metrics.recordDropped(s.name, "shutdown", 1)
+
+		return
+	}
+
+	if queued := s.queued.Add(1); s.queueSize > 0 && queued > s.queueSize {
+		s.queued.Add(-1)
+		s.metrics.recordDropped(s.name, "queue_full", 1)
+
+		return
+	}
+
+	s.metrics.queueLength.Add(context.Background(), 1, metric.WithAttributes(exporterKey.String(s.name)))
+	s.metrics.totals.queued.Add(1)
+	s.SpanProcessor.OnEnd(span)
+}
+
+func (s *observedSpanProcessor) Shutdown(ctx context.Context) error {
+	s.stopped.Store(true)
+
+	return s.SpanProcessor.Shutdown(ctx) //nolint:wrapcheck // the processor is transparent to the tracer provider
+}
+
+func (e *observedExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	attrs := metric.WithAttributes(exporterKey.String(e.name))
+	count := int64(len(spans))
+	start := time.Now()
+
+	err := e.next.ExportSpans(ctx, spans)
+
+	e.metrics.exportDuration.Record(ctx, time.Since(start).Seconds(), attrs)
+	e.queued.Add(-count)
+	e.metrics.queueLength.Add(ctx, -count, attrs)
+	e.metrics.totals.queued.Add(-count)
+
+	// skipped exports are already counted as dropped or spooled and are no failure
+	if errors.Is(err, errCircuitOpen) {
+		return nil
+	}
+
+	if err != nil {
+		e.metrics.spansFailed.Add(ctx, count, attrs)
+		e.metrics.totals.failed.Add(count)
+
+		return &exporterError{exporter: e.name, err: err}
+	}
+
+	e.metrics.spansExported.Add(ctx, count, attrs)
+	e.metrics.totals.exported.Add(count)
+
+	return nil
+}
+
+func (e *observedExporter) Shutdown(ctx context.Context) error {
+	if err := e.next.Shutdown(ctx); err != nil {
+		return fmt.Errorf("%s exporter shutdown failed: %w", e.name, err)
+	}
+
+	return nil
+}
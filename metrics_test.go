@@ -0,0 +1,242 @@
+package opentelemetry //nolint:testpackage // explicit testing of private pipeline metrics
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+var (
+	errExport     = errors.New("export error")
+	errInstrument = errors.New("instrument error")
+)
+
+type failingExporter struct{}
+
+// failingMeter can not create the exported spans counter
+type failingMeter struct {
+	metric.Meter
+}
+
+func (m failingMeter) Int64Counter(name string, options ...metric.Int64CounterOption) (metric.Int64Counter, error) {
+	if name == "flamingo.opentelemetry.exporter.spans.exported" {
+		return nil, errInstrument
+	}
+
+	return m.Meter.Int64Counter(name, options...) //nolint:wrapcheck // test meter
+}
+
+func (failingExporter) ExportSpans(context.Context, []tracesdk.ReadOnlySpan) error { return errExport }
+
+func (failingExporter) Shutdown(context.Context) error { return nil }
+
+func newBatchSpanProcessor(exp tracesdk.SpanExporter) tracesdk.SpanProcessor {
+	return tracesdk.NewBatchSpanProcessor(exp)
+}
+
+func newTestPipelineMetrics(t *testing.T) (*pipelineMetrics, *sdkMetric.ManualReader) {
+	t.Helper()
+
+	reader := sdkMetric.NewManualReader()
+	metrics, err := newPipelineMetrics(sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter(meterName))
+	require.NoError(t, err)
+
+	return metrics, reader
+}
+
+func sumOf(t *testing.T, reader *sdkMetric.ManualReader, name string, attrs ...attribute.KeyValue) int64 {
+	t.Helper()
+
+	var rm metricdata.ResourceMetrics
+
+	require.NoError(t, reader.Collect(context.Background(), &rm))
+
+	want := attribute.NewSet(attrs...)
+
+	var total int64
+
+	for _, sm := range rm.ScopeMetrics {
+		for _, m := range sm.Metrics {
+			if m.Name != name {
+				continue
+			}
+
+			sum, ok := m.Data.(metricdata.Sum[int64])
+			require.True(t, ok, "metric %s is not an int64 sum", name)
+
+			for _, dp := range sum.DataPoints {
+				if dp.Attributes.Equals(&want) {
+					total += dp.Value
+				}
+			}
+		}
+	}
+
+	return total
+}
+
+func TestPipelineMetrics_Sampler(t *testing.T) {
+	t.Parallel()
+
+	metrics, reader := newTestPipelineMetrics(t)
+
+	sampler := &alwaysSampleSpanKindClient{
+		base: &configuredURLPrefixSampler{
+			allowlist: []string{"/allowed"},
+			blocklist: []string{"/allowed/blocked"},
+			metrics:   metrics,
+		},
+		metrics: metrics,
+	}
+
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSampler(sampler),
+		tracesdk.WithSpanProcessor(&spanCounter{metrics: metrics}),
+	)
+	tracer := tp.Tracer("test")
+
+	for _, path := range []string{"/allowed/path", "/other", "/allowed/blocked/path"} {
+		_, span := tracer.Start(context.Background(), "request", trace.WithAttributes(semconv.URLPath(path)))
+		span.End()
+	}
+
+	_, span := tracer.Start(context.Background(), "client", trace.WithSpanKind(trace.SpanKindClient))
+	span.End()
+
+	assert.Equal(t, int64(2), sumOf(t, reader, "flamingo.opentelemetry.spans.started"))
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.sampler.decisions",
+		decisionKey.String("record_and_sample"), ruleKey.String(samplerRuleAllowlist)))
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.sampler.decisions",
+		decisionKey.String("drop"), ruleKey.String(samplerRuleAllowlist)))
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.sampler.decisions",
+		decisionKey.String("drop"), ruleKey.String(samplerRuleBlocklist)))
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.sampler.decisions",
+		decisionKey.String("record_and_sample"), ruleKey.String(samplerRuleClient)))
+}
+
+func TestPipelineMetrics_Exporter(t *testing.T) {
+	t.Parallel()
+
+	metrics, reader := newTestPipelineMetrics(t)
+
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSpanProcessor(metrics.observe("memory", tracetest.NewInMemoryExporter(), newBatchSpanProcessor, 0)),
+		tracesdk.WithSpanProcessor(metrics.observe("failing", failingExporter{}, newBatchSpanProcessor, 0)),
+	)
+
+	for range 3 {
+		_, span := tp.Tracer("test").Start(context.Background(), "span")
+		span.End()
+	}
+
+	assert.ErrorIs(t, tp.ForceFlush(context.Background()), errExport)
+
+	assert.Equal(t, int64(3), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.exported", exporterKey.String("memory")))
+	assert.Equal(t, int64(0), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.failed", exporterKey.String("memory")))
+	assert.Equal(t, int64(3), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.failed", exporterKey.String("failing")))
+	assert.Equal(t, int64(0), sumOf(t, reader, "flamingo.opentelemetry.processor.queue.length", exporterKey.String("memory")))
+	assert.Equal(t, int64(0), sumOf(t, reader, "flamingo.opentelemetry.processor.queue.length", exporterKey.String("failing")))
+}
+
+func TestPipelineMetrics_FailedInstrument(t *testing.T) {
+	t.Parallel()
+
+	reader := sdkMetric.NewManualReader()
+	metrics, err := newPipelineMetrics(failingMeter{Meter: sdkMetric.NewMeterProvider(sdkMetric.WithReader(reader)).Meter(meterName)})
+	require.ErrorIs(t, err, errInstrument)
+	require.NotNil(t, metrics, "the metrics are usable without the failed instrument")
+
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSpanProcessor(&spanCounter{metrics: metrics}),
+		tracesdk.WithSpanProcessor(metrics.observe("memory", tracetest.NewInMemoryExporter(), newBatchSpanProcessor, 0)),
+	)
+
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	span.End()
+
+	require.NoError(t, tp.ForceFlush(context.Background()))
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.spans.started"))
+	assert.Equal(t, int64(1), metrics.summary().exported)
+}
+
+func TestPipelineMetrics_QueueFull(t *testing.T) {
+	t.Parallel()
+
+	metrics, reader := newTestPipelineMetrics(t)
+	exp := tracetest.NewInMemoryExporter()
+
+	processor := metrics.observe("memory", exp, func(exp tracesdk.SpanExporter) tracesdk.SpanProcessor {
+		return tracesdk.NewBatchSpanProcessor(exp, tracesdk.WithMaxQueueSize(2), tracesdk.WithBatchTimeout(time.Hour))
+	}, 2)
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor))
+
+	for range 5 {
+		_, span := tp.Tracer("test").Start(context.Background(), "span")
+		span.End()
+	}
+
+	assert.Equal(t, int64(2), sumOf(t, reader, "flamingo.opentelemetry.processor.queue.length", exporterKey.String("memory")))
+	assert.Equal(t, int64(3), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.dropped",
+		exporterKey.String("memory"), reasonKey.String("queue_full")))
+
+	require.NoError(t, processor.Shutdown(context.Background()))
+
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	span.End()
+
+	assert.Equal(t, int64(0), sumOf(t, reader, "flamingo.opentelemetry.processor.queue.length", exporterKey.String("memory")))
+	assert.Equal(t, int64(2), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.exported", exporterKey.String("memory")))
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.dropped",
+		exporterKey.String("memory"), reasonKey.String("shutdown")))
+	assert.Equal(t, pipelineSummary{exported: 2, dropped: 4}, metrics.summary())
+}
+
+func TestListener_Notify_Summary(t *testing.T) {
+	t.Parallel()
+
+	metrics, _ := newTestPipelineMetrics(t)
+	logger := newRecordingLogger()
+
+	processor := metrics.observe("memory", tracetest.NewInMemoryExporter(), func(exp tracesdk.SpanExporter) tracesdk.SpanProcessor {
+		return tracesdk.NewBatchSpanProcessor(exp, tracesdk.WithMaxQueueSize(2), tracesdk.WithBatchTimeout(time.Hour))
+	}, 2)
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processor))
+
+	for range 3 {
+		_, span := tp.Tracer("test").Start(context.Background(), "span")
+		span.End()
+	}
+
+	listener := &Listener{logger: logger, metrics: metrics, components: []component{providerComponent("tracer provider", tp)}}
+	listener.Notify(context.Background(), new(flamingo.ShutdownEvent))
+
+	assert.Contains(t, logger.Messages(),
+		"flushed telemetry on shutdown: 2 spans exported, 0 failed, 0 left in the queues, 1 dropped since the start")
+}
+
+func TestPipelineMetrics_ErrorHandler(t *testing.T) {
+	t.Parallel()
+
+	metrics, reader := newTestPipelineMetrics(t)
+
+	handler := newErrorHandler(new(flamingo.NullLogger), 0, 0)
+	handler.Handle(errExport)
+	handler.setMetrics(metrics)
+	handler.Handle(errExport)
+
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.errors", categoryKey.String(errorCategoryInternal)))
+}
@@ -4,22 +4,24 @@ package opentelemetry
 
 import (
 	"context"
+	"errors"
 	"fmt"
-	"log"
+	"io"
 	"net/http"
-	"net/url"
+	"os"
+	"time"
 
 	"flamingo.me/dingo"
 	"github.com/prometheus/client_golang/prometheus/promhttp"
 	"github.com/prometheus/otlptranslator"
+	"github.com/spf13/cobra"
+	octrace "go.opencensus.io/trace"
 	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
 	runtimemetrics "go.opentelemetry.io/contrib/instrumentation/runtime"
 	"go.opentelemetry.io/otel"
 	"go.opentelemetry.io/otel/bridge/opencensus"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
-	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
 	"go.opentelemetry.io/otel/exporters/prometheus"
-	"go.opentelemetry.io/otel/exporters/zipkin" //nolint:staticcheck // the deprecated integration will be removed in issue https://github.com/i-love-flamingo/opentelemetry/issues/82
+	"go.opentelemetry.io/otel/metric"
 	"go.opentelemetry.io/otel/propagation"
 	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
 	"go.opentelemetry.io/otel/sdk/resource"
@@ -27,207 +29,524 @@ import (
 	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
 	"go.opentelemetry.io/otel/trace"
 
+	"flamingo.me/flamingo/v3/framework/config"
 	"flamingo.me/flamingo/v3/framework/flamingo"
 	flamingoHttp "flamingo.me/flamingo/v3/framework/http"
 	"flamingo.me/flamingo/v3/framework/systemendpoint"
 	"flamingo.me/flamingo/v3/framework/systemendpoint/domain"
+	"flamingo.me/flamingo/v3/framework/web"
 )
 
+// InstrumentedAnnotation marks the instrumented *http.Client and http.RoundTripper bound by the module
+const InstrumentedAnnotation = "opentelemetry"
+
 type Module struct {
 	sampler                          *configuredURLPrefixSampler
 	serviceName                      string
 	publicEndpoint                   bool
-	zipkinEnable                     bool
+	zipkinEnable                     *bool
 	zipkinEndpoint                   string
-	otlpEnableHTTP                   bool
+	zipkinOptions                    exporterConfig
+	otlpEnableHTTP                   *bool
 	otlpEndpointHTTP                 string
-	otlpEnableGRPC                   bool
+	otlpHTTPOptions                  exporterConfig
+	otlpEnableGRPC                   *bool
 	otlpEndpointGRPC                 string
+	otlpGRPCOptions                  exporterConfig
 	legacyPrometheusNamingSanitation bool
+	lenient                          bool
+	propagators                      []string
+	propagatorsSource                string
+	exporters                        []exporterConfig
+	stdout                           stdoutConfig
+	stdoutOutput                     io.Writer
+	traceTree                        traceTreeConfig
+	exporterTransport                transportConfig
+	instrumentDefaultTransport       bool
+	fallbackSpanName                 string
+	actions                          actionConfig
+	templates                        templateConfig
+	events                           eventConfig
+	commands                         commandConfig
+	setGlobals                       bool
+	disableExport                    bool
+	shutdownTimeout                  time.Duration
+	components                       []component
+	logger                           flamingo.Logger
+	errorHandler                     *errorHandler
+	metrics                          *pipelineMetrics
+	problems                         []error
 }
 
 func (m *Module) Inject(
 	sampler *configuredURLPrefixSampler,
 	logger flamingo.Logger,
 	cfg *struct {
-		ServiceName                      string `inject:"config:flamingo.opentelemetry.serviceName"`
-		PublicEndpoint                   bool   `inject:"config:flamingo.opentelemetry.publicEndpoint"`
-		ZipkinEnable                     bool   `inject:"config:flamingo.opentelemetry.zipkin.enable"`
-		ZipkinEndpoint                   string `inject:"config:flamingo.opentelemetry.zipkin.endpoint"`
-		OTLPEnableHTTP                   bool   `inject:"config:flamingo.opentelemetry.otlp.http.enable"`
-		OTLPEndpointHTTP                 string `inject:"config:flamingo.opentelemetry.otlp.http.endpoint"`
-		OTLPEnableGRPC                   bool   `inject:"config:flamingo.opentelemetry.otlp.grpc.enable"`
-		OTLPEndpointGRPC                 string `inject:"config:flamingo.opentelemetry.otlp.grpc.endpoint"`
-		LegacyPrometheusNamingSanitation bool   `inject:"config:flamingo.opentelemetry.legacyPrometheusNamingSanitation"`
+		ServiceName                      string       `inject:"config:flamingo.opentelemetry.serviceName"`
+		PublicEndpoint                   bool         `inject:"config:flamingo.opentelemetry.publicEndpoint"`
+		ZipkinEndpoint                   string       `inject:"config:flamingo.opentelemetry.zipkin.endpoint"`
+		OTLPEndpointHTTP                 string       `inject:"config:flamingo.opentelemetry.otlp.http.endpoint"`
+		OTLPEndpointGRPC                 string       `inject:"config:flamingo.opentelemetry.otlp.grpc.endpoint"`
+		LegacyPrometheusNamingSanitation bool         `inject:"config:flamingo.opentelemetry.legacyPrometheusNamingSanitation"`
+		ErrorHandlerLimit                float64      `inject:"config:flamingo.opentelemetry.errorHandler.limit"`
+		ErrorHandlerInterval             string       `inject:"config:flamingo.opentelemetry.errorHandler.interval"`
+		Lenient                          bool         `inject:"config:flamingo.opentelemetry.lenient"`
+		SetGlobals                       bool         `inject:"config:flamingo.opentelemetry.setGlobals,optional"`
+		DisableExport                    bool         `inject:"config:flamingo.opentelemetry.disableExport,optional"`
+		InstrumentDefaultTransport       bool         `inject:"config:flamingo.opentelemetry.instrumentDefaultTransport,optional"`
+		FallbackSpanName                 string       `inject:"config:flamingo.opentelemetry.tracing.fallbackSpanName,optional"`
+		Propagators                      config.Slice `inject:"config:flamingo.opentelemetry.propagators,optional"`
+		Exporters                        config.Slice `inject:"config:flamingo.opentelemetry.exporters,optional"`
+		Zipkin                           config.Map   `inject:"config:flamingo.opentelemetry.zipkin,optional"`
+		OTLPHTTP                         config.Map   `inject:"config:flamingo.opentelemetry.otlp.http,optional"`
+		OTLPGRPC                         config.Map   `inject:"config:flamingo.opentelemetry.otlp.grpc,optional"`
+		Stdout                           config.Map   `inject:"config:flamingo.opentelemetry.stdout,optional"`
+		TraceTree                        config.Map   `inject:"config:flamingo.opentelemetry.traceTree,optional"`
+		ExporterTransport                config.Map   `inject:"config:flamingo.opentelemetry.exporterTransport,optional"`
+		Actions                          config.Map   `inject:"config:flamingo.opentelemetry.tracing.actions,optional"`
+		Templates                        config.Map   `inject:"config:flamingo.opentelemetry.tracing.templates,optional"`
+		Events                           config.Map   `inject:"config:flamingo.opentelemetry.tracing.events,optional"`
+		Commands                         config.Map   `inject:"config:flamingo.opentelemetry.commands,optional"`
+		ShutdownTimeout                  string       `inject:"config:flamingo.opentelemetry.shutdown.timeout,optional"`
 	},
 ) *Module {
 	m.sampler = sampler
+	m.logger = logger
+
+	var (
+		errorLimit    int
+		errorInterval time.Duration
+	)
 
 	if cfg != nil {
 		m.serviceName = cfg.ServiceName
 		m.publicEndpoint = cfg.PublicEndpoint
-		m.zipkinEnable = cfg.ZipkinEnable
 		m.zipkinEndpoint = cfg.ZipkinEndpoint
-		m.otlpEnableHTTP = cfg.OTLPEnableHTTP
 		m.otlpEndpointHTTP = cfg.OTLPEndpointHTTP
-		m.otlpEnableGRPC = cfg.OTLPEnableGRPC
 		m.otlpEndpointGRPC = cfg.OTLPEndpointGRPC
 		m.legacyPrometheusNamingSanitation = cfg.LegacyPrometheusNamingSanitation
+		m.lenient = cfg.Lenient
+		m.setGlobals = cfg.SetGlobals
+		m.disableExport = cfg.DisableExport
+		m.instrumentDefaultTransport = cfg.InstrumentDefaultTransport
+		m.fallbackSpanName = cfg.FallbackSpanName
+		errorLimit = int(cfg.ErrorHandlerLimit)
+
+		var err error
+
+		errorInterval, err = time.ParseDuration(cfg.ErrorHandlerInterval)
+		if err != nil {
+			m.problems = append(m.problems, fmt.Errorf("failed to parse flamingo.opentelemetry.errorHandler.interval: %w", err))
+		}
+
+		if cfg.ShutdownTimeout != "" {
+			m.shutdownTimeout, err = time.ParseDuration(cfg.ShutdownTimeout)
+			if err == nil && m.shutdownTimeout <= 0 {
+				err = errInvalidShutdownTimeout
+			}
+
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to parse flamingo.opentelemetry.shutdown.timeout: %w", err))
+			}
+		}
+
+		if cfg.Propagators != nil {
+			// an empty list disables the propagation, only a missing list falls back to the defaults
+			m.propagators = []string{}
+
+			err = cfg.Propagators.MapInto(&m.propagators)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map flamingo.opentelemetry.propagators: %w", err))
+			}
+		}
+
+		if cfg.Exporters != nil {
+			err = cfg.Exporters.MapInto(&m.exporters)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map flamingo.opentelemetry.exporters: %w", err))
+			}
+		}
+
+		// the remaining settings of the single exporters, enable is left unset unless configured
+		for _, single := range []struct {
+			key    string
+			cfg    config.Map
+			enable **bool
+			target *exporterConfig
+		}{
+			{key: "flamingo.opentelemetry.zipkin", cfg: cfg.Zipkin, enable: &m.zipkinEnable, target: &m.zipkinOptions},
+			{key: "flamingo.opentelemetry.otlp.http", cfg: cfg.OTLPHTTP, enable: &m.otlpEnableHTTP, target: &m.otlpHTTPOptions},
+			{key: "flamingo.opentelemetry.otlp.grpc", cfg: cfg.OTLPGRPC, enable: &m.otlpEnableGRPC, target: &m.otlpGRPCOptions},
+		} {
+			if single.cfg == nil {
+				continue
+			}
+
+			if enable, ok := single.cfg["enable"].(bool); ok {
+				*single.enable = &enable
+			}
+
+			err = single.cfg.MapInto(single.target)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map %s: %w", single.key, err))
+			}
+		}
+
+		if cfg.Stdout != nil {
+			err = cfg.Stdout.MapInto(&m.stdout)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map flamingo.opentelemetry.stdout: %w", err))
+			}
+		}
+
+		if cfg.TraceTree != nil {
+			err = cfg.TraceTree.MapInto(&m.traceTree)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map flamingo.opentelemetry.traceTree: %w", err))
+			}
+		}
+
+		if cfg.ExporterTransport != nil {
+			err = cfg.ExporterTransport.MapInto(&m.exporterTransport)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map flamingo.opentelemetry.exporterTransport: %w", err))
+			}
+		}
+
+		if cfg.Actions != nil {
+			err = cfg.Actions.MapInto(&m.actions)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map flamingo.opentelemetry.tracing.actions: %w", err))
+			}
+		}
+
+		if cfg.Templates != nil {
+			err = cfg.Templates.MapInto(&m.templates)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map flamingo.opentelemetry.tracing.templates: %w", err))
+			}
+		}
+
+		if cfg.Events != nil {
+			err = cfg.Events.MapInto(&m.events)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map flamingo.opentelemetry.tracing.events: %w", err))
+			}
+		}
+
+		if cfg.Commands != nil {
+			err = cfg.Commands.MapInto(&m.commands)
+			if err != nil {
+				m.problems = append(m.problems, fmt.Errorf("failed to map flamingo.opentelemetry.commands: %w", err))
+			}
+		}
+	}
+
+	if m.fallbackSpanName == "" {
+		m.fallbackSpanName = defaultFallbackSpanName
+	}
+
+	if err := m.applyEnvironment(os.LookupEnv); err != nil {
+		m.problems = append(m.problems, err)
 	}
 
-	otel.SetErrorHandler(newErrorHandler(logger))
+	m.errorHandler = newErrorHandler(logger, errorLimit, errorInterval)
+	if m.setGlobals {
+		otel.SetErrorHandler(m.errorHandler)
+	}
 
 	return m
 }
 
 func (m *Module) Configure(injector *dingo.Injector) {
-	http.DefaultTransport = &correlationIDInjector{
-		next: otelhttp.NewTransport(http.DefaultTransport),
-	}
+	m.register(component{name: "error handler", close: m.errorHandler.closeWindows})
 
-	injector.Bind(new(flamingoHttp.HandlerWrapper)).ToProvider(func() flamingoHttp.HandlerWrapper {
-		return func(handler http.Handler) http.Handler {
-			const maxOptions = 2
+	stdoutErr := m.openStdout()
+	meterProvider, metricsErr := m.initMetrics(injector)
+	tracerProvider, tracesErr := m.initTraces()
+	propagator, propagatorErr := newPropagator(m.propagators, firstNonEmpty(m.propagatorsSource, "flamingo.opentelemetry.propagators"))
 
-			startOptions := make([]otelhttp.Option, 0, maxOptions)
-			startOptions = append(
-				startOptions,
-				otelhttp.WithSpanNameFormatter(func(operation string, r *http.Request) string {
-					return operation + ": " + r.URL.Path
-				}),
-			)
+	// entries without a leading / never match, but they were accepted so far and must not stop the application
+	if err := m.sampler.validate(); err != nil {
+		m.errorHandler.log(classifyError(err)).Warnf("sampler list entries are ignored: %v", err)
+	}
 
-			if m.publicEndpoint {
-				startOptions = append(startOptions, otelhttp.WithPublicEndpointFn(func(*http.Request) bool { return true }))
-			}
+	err := newConfigurationError(
+		errors.Join(m.problems...),
+		errors.Join(m.sampler.problems...),
+		stdoutErr,
+		metricsErr,
+		tracesErr,
+		propagatorErr,
+	)
+	if err != nil {
+		if !m.lenient {
+			// stop what is already running, e.g. the batch processors and the runtime metrics, before failing
+			ctx, cancel := context.WithTimeout(context.Background(), shutdownTimeout(m.shutdownTimeout))
+			defer cancel()
 
-			return otelhttp.NewHandler(
-				handler,
-				"incoming request",
-				startOptions...,
-			)
+			panic(errors.Join(err, closeComponents(ctx, m.components)))
 		}
+
+		m.log().Errorf("continuing with no-op exporters for the failed parts: %v", err)
+	}
+
+	// the providers are injectable, so other modules and tests do not depend on the otel globals
+	injector.Bind(new(trace.TracerProvider)).ToInstance(tracerProvider)
+	injector.Bind(new(metric.MeterProvider)).ToInstance(meterProvider)
+	injector.Bind(new(propagation.TextMapPropagator)).ToInstance(propagator)
+
+	if m.setGlobals {
+		otel.SetMeterProvider(meterProvider)
+		otel.SetTracerProvider(tracerProvider)
+
+		opencensus.InstallTraceBridge(opencensus.WithTracerProvider(tracerProvider))
+		octrace.DefaultTracer = newBridgeTracer(octrace.DefaultTracer, m.errorHandler)
+
+		// https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/context/api-propagators.md#propagators-distribution
+		otel.SetTextMapPropagator(propagator)
+	}
+
+	m.bindInstrumentation(injector, tracerProvider, meterProvider, propagator)
+
+	flamingo.BindEventSubscriber(injector).ToInstance(&Listener{
+		logger:     m.logger,
+		timeout:    m.shutdownTimeout,
+		metrics:    m.metrics,
+		components: m.components,
 	})
+}
+
+// bindInstrumentation binds the request, action, template, event and command instrumentation, the providers are
+// resolved by dingo so that a later module, e.g. opentelemetrytest.Module, can override them
+func (m *Module) bindInstrumentation(
+	injector *dingo.Injector,
+	tracerProvider trace.TracerProvider,
+	meterProvider metric.MeterProvider,
+	propagator propagation.TextMapPropagator,
+) {
+	if m.instrumentDefaultTransport {
+		http.DefaultTransport = instrumentTransport(http.DefaultTransport, providerOptions(tracerProvider, meterProvider, propagator)...)
+	}
+
+	// explicit opt-in for single clients, injected with the InstrumentedAnnotation
+	injector.Bind(new(http.RoundTripper)).AnnotatedWith(InstrumentedAnnotation).ToProvider(
+		func(tp trace.TracerProvider, mp metric.MeterProvider, p propagation.TextMapPropagator) http.RoundTripper {
+			return instrumentTransport(http.DefaultTransport, providerOptions(tp, mp, p)...)
+		},
+	)
+	injector.Bind(new(http.Client)).AnnotatedWith(InstrumentedAnnotation).ToProvider(
+		func(tp trace.TracerProvider, mp metric.MeterProvider, p propagation.TextMapPropagator) *http.Client {
+			return &http.Client{Transport: instrumentTransport(http.DefaultTransport, providerOptions(tp, mp, p)...)}
+		},
+	)
+
+	injector.Bind(new(flamingoHttp.HandlerWrapper)).ToProvider(
+		func(tp trace.TracerProvider, mp metric.MeterProvider, p propagation.TextMapPropagator) flamingoHttp.HandlerWrapper {
+			return func(handler http.Handler) http.Handler {
+				const maxOptions = 5
+
+				startOptions := make([]otelhttp.Option, 0, maxOptions)
+				startOptions = append(
+					startOptions,
+					// replaced by the route once the router matched the request
+					otelhttp.WithSpanNameFormatter(func(string, *http.Request) string {
+						return m.fallbackSpanName
+					}),
+				)
+				startOptions = append(startOptions, providerOptions(tp, mp, p)...)
+
+				if m.publicEndpoint {
+					startOptions = append(startOptions, otelhttp.WithPublicEndpointFn(func(*http.Request) bool { return true }))
+				}
+
+				return otelhttp.NewHandler(
+					handler,
+					"incoming request",
+					startOptions...,
+				)
+			}
+		},
+	)
+
+	injector.BindMulti(new(web.Filter)).To(new(routeSpanNamer))
 
-	flamingo.BindEventSubscriber(injector).To(new(Listener))
+	if m.actions.Enable {
+		injector.BindMulti(new(web.Filter)).ToProvider(func(tp trace.TracerProvider) web.Filter {
+			return newActionTracer(m.actions, tp)
+		})
+	}
+
+	// dingo picks the interceptor type from the config alone, so partial rendering is offered only if it is enabled
+	if m.templates.Enable && m.templates.Partials {
+		injector.BindInterceptor(new(flamingo.TemplateEngine), partialTemplateEngineInterceptor{})
+	} else if m.templates.Enable {
+		injector.BindInterceptor(new(flamingo.TemplateEngine), templateEngineInterceptor{})
+	}
+
+	if m.events.Enable {
+		injector.Override(new(flamingo.EventRouter), "").To(new(tracingEventRouter))
+	}
 
-	m.initTraces()
-	m.initMetrics(injector)
+	if m.commands.Enable {
+		commands := newCommandTracer(m.commands, m.errorHandler)
+
+		injector.BindMulti(new(cobra.Command)).ToInstance(commands.anchor)
+		// the subscriber is resolved once, so the instruments are created and the providers are looked up only once
+		flamingo.BindEventSubscriber(injector).ToProvider(func(tp trace.TracerProvider, mp metric.MeterProvider) flamingo.Subscriber {
+			return commands.Inject(tp, mp)
+		}).In(dingo.Singleton)
+	}
+}
+
+// providerOptions makes otelhttp use the bound providers instead of the otel globals
+func providerOptions(tp trace.TracerProvider, mp metric.MeterProvider, p propagation.TextMapPropagator) []otelhttp.Option {
+	return []otelhttp.Option{
+		otelhttp.WithTracerProvider(tp),
+		otelhttp.WithMeterProvider(mp),
+		otelhttp.WithPropagators(p),
+	}
 }
 
-func (m *Module) initTraces() {
-	const maxTracerProviderOptions = 5
+// initTraces creates the tracer provider, exporters which could not be created are reported and left out
+func (m *Module) initTraces() (*tracesdk.TracerProvider, error) {
+	const maxTracerProviderOptions = 3
+
+	var (
+		processors   []tracesdk.SpanProcessor
+		exportersErr error
+	)
+
+	if !m.disableExport {
+		processors, exportersErr = m.initExporters()
+	}
+
+	var treeErr error
 
-	tracerProviderOptions := make([]tracesdk.TracerProviderOption, 0, maxTracerProviderOptions)
-	tracerProviderOptions = m.initOTLP(tracerProviderOptions)
-	tracerProviderOptions = m.initZipkin(tracerProviderOptions)
+	if m.traceTree.Enable {
+		var printer *traceTreePrinter
 
-	res, err := resource.Merge(resource.Default(),
+		printer, treeErr = newTraceTreePrinter(m.logger, m.traceTree)
+		if treeErr == nil {
+			processors = append(processors, printer)
+		}
+	}
+
+	tracerProviderOptions := make([]tracesdk.TracerProviderOption, 0, len(processors)+maxTracerProviderOptions)
+	for _, processor := range processors {
+		tracerProviderOptions = append(tracerProviderOptions, tracesdk.WithSpanProcessor(processor))
+	}
+
+	// on a schema URL conflict the merged resource is still usable
+	res, resourceErr := resource.Merge(resource.Default(),
 		resource.NewWithAttributes(
 			semconv.SchemaURL,
 			semconv.ServiceName(m.serviceName),
 			semconv.ServiceVersion(flamingo.AppVersion()),
 			semconv.TelemetrySDKLanguageGo,
 		))
-	if err != nil {
-		log.Fatalf("failed to initialize otel resource: %v", err)
+	if resourceErr != nil {
+		resourceErr = fmt.Errorf("failed to initialize otel resource: %w", resourceErr)
+	}
+
+	m.sampler.metrics = m.metrics
+
+	var (
+		sampler    tracesdk.Sampler = m.sampler
+		commandErr error
+	)
+
+	if m.commands.Enable {
+		var commands *commandSampler
+
+		commands, commandErr = newCommandSampler(m.sampler, m.commands, m.metrics)
+		if commandErr == nil {
+			sampler = commands
+		}
 	}
 
 	tracerProviderOptions = append(tracerProviderOptions,
 		tracesdk.WithResource(res),
 		tracesdk.WithSampler(
 			&alwaysSampleSpanKindClient{
-				base: m.sampler,
+				base:    sampler,
+				metrics: m.metrics,
 			},
 		),
+		tracesdk.WithSpanProcessor(&spanCounter{metrics: m.metrics}),
 	)
 
-	tp := tracesdk.NewTracerProvider(tracerProviderOptions...)
-	otel.SetTracerProvider(tp)
+	tracerProvider := tracesdk.NewTracerProvider(tracerProviderOptions...)
+	m.register(providerComponent("tracer provider", tracerProvider))
 
-	opencensus.InstallTraceBridge(opencensus.WithTracerProvider(tp))
-
-	// https://github.com/open-telemetry/opentelemetry-specification/blob/main/specification/context/api-propagators.md#propagators-distribution
-	otel.SetTextMapPropagator(propagation.NewCompositeTextMapPropagator(propagation.TraceContext{}, propagation.Baggage{}))
+	return tracerProvider, errors.Join(exportersErr, treeErr, resourceErr, commandErr)
 }
 
-// Create the OTLP HTTP exporter
-func (m *Module) initOTLP(tracerProviderOptions []tracesdk.TracerProviderOption) []tracesdk.TracerProviderOption {
-	if m.otlpEnableHTTP {
-		u, err := url.Parse(m.otlpEndpointHTTP)
-		if err != nil {
-			log.Fatalf("could not parse OTLP HTTP endpoint: %v", err)
-		}
-
-		opts := []otlptracehttp.Option{
-			otlptracehttp.WithEndpoint(u.Host),
-			otlptracehttp.WithURLPath(u.Path),
-		}
-		if u.Scheme == "http" {
-			opts = append(opts, otlptracehttp.WithInsecure())
-		}
-
-		exp, err := otlptracehttp.New(context.Background(), opts...)
-		if err != nil {
-			log.Fatalf("failed to initialze OTLP HTTP exporter: %v", err)
-		}
+// initMetrics creates the meter provider, without the Prometheus reader if the exporter could not be created
+func (m *Module) initMetrics(injector *dingo.Injector) (*sdkMetric.MeterProvider, error) {
+	var errs []error
 
-		tracerProviderOptions = append(tracerProviderOptions, tracesdk.WithBatcher(exp))
+	options := []prometheus.Option{
+		prometheus.WithProducer(bridgeMetricProducer{Producer: opencensus.NewMetricProducer()}),
 	}
 
-	// Create the OTLP gRPC exporter
-	if m.otlpEnableGRPC {
-		exp, err := otlptracegrpc.New(context.Background(), otlptracegrpc.WithEndpoint(m.otlpEndpointGRPC))
-		if err != nil {
-			log.Fatalf("failed to initialze OTLP gRPC exporter: %v", err)
-		}
-
-		tracerProviderOptions = append(tracerProviderOptions, tracesdk.WithBatcher(exp))
+	if m.legacyPrometheusNamingSanitation {
+		options = append(options, prometheus.WithTranslationStrategy(otlptranslator.UnderscoreEscapingWithSuffixes))
 	}
 
-	return tracerProviderOptions
-}
+	var meterProviderOptions []sdkMetric.Option
 
-// Create the Zipkin exporter
-func (m *Module) initZipkin(tracerProviderOptions []tracesdk.TracerProviderOption) []tracesdk.TracerProviderOption {
-	if m.zipkinEnable {
-		exp, err := zipkin.New(
-			m.zipkinEndpoint,
+	if !m.disableExport {
+		exp, err := prometheus.New(
+			options...,
 		)
 		if err != nil {
-			log.Fatalf("failed to initialize Zipkin exporter: %v", err)
+			errs = append(errs, fmt.Errorf("failed to initialize Prometheus exporter: %w", err))
+		} else {
+			meterProviderOptions = append(meterProviderOptions, sdkMetric.WithReader(exp))
 		}
-
-		tracerProviderOptions = append(tracerProviderOptions, tracesdk.WithBatcher(exp))
 	}
 
-	return tracerProviderOptions
-}
-
-func (m *Module) initMetrics(injector *dingo.Injector) {
-	options := []prometheus.Option{
-		prometheus.WithProducer(opencensus.NewMetricProducer()),
+	if m.stdout.Metrics && m.stdoutOutput != nil {
+		reader, err := m.stdoutMetricReader()
+		if err != nil {
+			errs = append(errs, err)
+		} else {
+			meterProviderOptions = append(meterProviderOptions, sdkMetric.WithReader(reader))
+		}
 	}
 
-	if m.legacyPrometheusNamingSanitation {
-		options = append(options, prometheus.WithTranslationStrategy(otlptranslator.UnderscoreEscapingWithSuffixes))
-	}
+	meterProvider := sdkMetric.NewMeterProvider(meterProviderOptions...)
+	m.register(providerComponent("meter provider", meterProvider))
 
-	exp, err := prometheus.New(
-		options...,
-	)
+	// the metrics are usable even if some instruments could not be created, those are replaced by no-op instruments
+	metrics, err := newPipelineMetrics(meterProvider.Meter(meterName))
 	if err != nil {
-		log.Fatalf("failed to initialize Prometheus exporter: %v", err)
+		errs = append(errs, err)
 	}
 
-	meterProvider := sdkMetric.NewMeterProvider(sdkMetric.WithReader(exp))
-	otel.SetMeterProvider(meterProvider)
+	m.metrics = metrics
+	m.errorHandler.setMetrics(m.metrics)
+
+	if !m.disableExport {
+		// the runtime metrics can not be stopped, their callbacks are unregistered instead
+		runtimeMeterProvider := &callbackMeterProvider{MeterProvider: meterProvider}
+		if err := runtimemetrics.Start(runtimemetrics.WithMeterProvider(runtimeMeterProvider)); err != nil {
+			errs = append(errs, fmt.Errorf("failed to start runtime metrics: %w", err))
+		}
 
-	if err := runtimemetrics.Start(); err != nil {
-		log.Fatal(err)
+		m.register(component{name: "runtime metrics", close: runtimeMeterProvider.unregister})
 	}
 
 	injector.BindMap((*domain.Handler)(nil), "/metrics").ToInstance(promhttp.Handler())
+
+	return meterProvider, errors.Join(errs...)
+}
+
+func (m *Module) log() flamingo.Logger {
+	return m.logger.
+		WithField(flamingo.LogKeyModule, "opentelemetry").
+		WithField(flamingo.LogKeyCategory, "configuration")
 }
 
 func (m *Module) Depends() []dingo.Module {
@@ -236,6 +555,16 @@ func (m *Module) Depends() []dingo.Module {
 	}
 }
 
+// instrumentTransport adds client spans and the correlation ID header to base, already instrumented transports are
+// returned unchanged so that repeated configuration does not wrap them again
+func instrumentTransport(base http.RoundTripper, options ...otelhttp.Option) http.RoundTripper {
+	if _, ok := base.(*correlationIDInjector); ok {
+		return base
+	}
+
+	return &correlationIDInjector{next: otelhttp.NewTransport(base, options...)}
+}
+
 type correlationIDInjector struct {
 	next http.RoundTripper
 }
@@ -257,27 +586,137 @@ func (rt *correlationIDInjector) RoundTrip(req *http.Request) (*http.Response, e
 func (m *Module) CueConfig() string {
 	return `
 flamingo: opentelemetry: {
-	zipkin: {
-		enable: bool | *false
+	// the settings shared by the exporters, hidden fields stand in for definitions which the cue version lacks
+	_processor: {
+		processor: "batch" | "simple" | *"batch"
+		batch: {
+			maxQueueSize: number | *0
+			maxExportBatchSize: number | *0
+			scheduleDelay: string | *""
+			exportTimeout: string | *""
+		}
+	}
+	_exporter: _processor & {
+		spool: {
+			directory: string | *""
+			maxSize: number | *100
+			maxAge: string | *"24h"
+		}
+		circuitBreaker: {
+			failureThreshold: number | *0
+			coolDown: string | *"30s"
+		}
+	}
+	_otlp: _exporter & {
+		compression: "none" | "gzip" | *"none"
+		timeout: string | *""
+		retry: {
+			enabled: bool | *true
+			initialInterval: string | *""
+			maxInterval: string | *""
+			maxElapsedTime: string | *""
+		}
+	}
+	// unset enable flags and values left at their default are taken from the OTEL_* environment variables
+	zipkin: _exporter & {
+		enable?: bool
 		endpoint: string | *"http://localhost:9411/api/v2/spans"
 	}
 	otlp: {
-		http: {
-			enable: bool | *false
+		http: _otlp & {
+			enable?: bool
 			endpoint: string | *"http://localhost:4318/v1/traces"
+			encoding: "protobuf" | "json" | *"protobuf"
 		}
-		grpc: {
-			enable: bool | *false
+		grpc: _otlp & {
+			enable?: bool
 			endpoint: string | *"grpc://localhost:4317/v1/traces"
 		}
 	}
 	serviceName: string | *"flamingo"
 	publicEndpoint: bool | *true
-	tracing: sampler: {
-		allowlist: [...string]
-		blocklist: [...string]
+	tracing: {
+		sampler: {
+			allowlist: [...string]
+			blocklist: [...string]
+		}
+		// span name of requests which matched no route
+		fallbackSpanName: string | *"incoming request"
+		// child spans for the controller actions, params lists the route params recorded as attributes
+		actions: {
+			enable: bool | *true
+			params: [...string]
+		}
+		// spans around the rendering of templates and partials of the bound template engine, enable partials only for
+		// engines with partial rendering
+		templates: {
+			enable: bool | *false
+			partials: bool | *false
+		}
+		// spans for every event notification of a subscriber inside a trace
+		events: enable: bool | *false
 	}
 	legacyPrometheusNamingSanitation: bool | *true
+	errorHandler: {
+		limit: number | *10
+		interval: string | *"1m"
+	}
+	// the telemetry is flushed and the providers are shut down within the timeout
+	shutdown: timeout: string | *"5s"
+	propagators: *["tracecontext", "baggage"] | [...string]
+	// wraps http.DefaultTransport for all libraries, otherwise inject the client or round tripper annotated "opentelemetry"
+	instrumentDefaultTransport: bool | *false
+	stdout: _processor & {
+		traces: bool | *false
+		metrics: bool | *false
+		format: "pretty" | "json" | *"pretty"
+		path: string | *""
+		maxSize: number | *100
+		maxBackups: number | *3
+		metricsInterval: string | *"10s"
+	}
+	// root spans for CLI commands, sampled by their own ratio
+	commands: {
+		enable: bool | *false
+		sampleRatio: number | *1
+		exclude: *["serve"] | [...string]
+		redact: *["password", "secret", "token", "key"] | [...string]
+	}
+	// development only: logs slow or failing requests as span tree
+	traceTree: {
+		enable: bool | *false
+		threshold: string | *"0s"
+		attributes: [...string]
+	}
+	// connections of the exporters, the proxy environment variables are ignored
+	exporterTransport: {
+		proxy: string | *""
+		noProxy: [...string]
+		maxIdleConns: number | *0
+		maxIdleConnsPerHost: number | *0
+		maxConnsPerHost: number | *0
+		idleConnTimeout: string | *""
+		certificate: string | *""
+		clientCertificate: string | *""
+		clientKey: string | *""
+	}
+	exporters: [..._otlp & {
+		name: string
+		protocol: "otlp.http" | "otlp.grpc" | "zipkin"
+		endpoint: string
+		headers: {...}
+		encoding: "protobuf" | "json" | *"protobuf"
+		filter: {
+			attributes: {...}
+			scopes: [...string]
+			services: [...string]
+		}
+	}]
+	lenient: bool | *false
+	// false keeps the otel globals untouched, the providers are only injectable then
+	setGlobals: bool | *true
+	// true skips the exporters, readers and runtime metrics, set by the opentelemetrytest module
+	disableExport: bool | *false
 }
 `
 }
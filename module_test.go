@@ -1,17 +1,32 @@
 package opentelemetry_test
 
 import (
+	"context"
+	"encoding/pem"
+	"net/http"
+	"os"
+	"path/filepath"
 	"testing"
 
 	"flamingo.me/dingo"
 	"flamingo.me/flamingo/v3/framework/config"
 	"flamingo.me/flamingo/v3/framework/flamingo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
 
 	"flamingo.me/opentelemetry"
+	"flamingo.me/opentelemetry/opentelemetrytest"
 )
 
 type (
 	loggerModule struct{}
+
+	// providerModule is configured after the opentelemetry module and receives the bound tracer provider
+	providerModule struct {
+		tracerProvider trace.TracerProvider
+	}
 )
 
 // Configure DI
@@ -19,6 +34,20 @@ func (m *loggerModule) Configure(injector *dingo.Injector) {
 	injector.Bind(new(flamingo.Logger)).To(new(flamingo.NullLogger))
 }
 
+// Inject dependencies
+func (m *providerModule) Inject(tracerProvider trace.TracerProvider) *providerModule {
+	m.tracerProvider = tracerProvider
+
+	return m
+}
+
+// Configure DI
+func (m *providerModule) Configure(*dingo.Injector) {}
+
+func (m *providerModule) Depends() []dingo.Module {
+	return []dingo.Module{new(opentelemetry.Module)}
+}
+
 func TestModule_Configure(t *testing.T) {
 	t.Parallel()
 
@@ -26,3 +55,145 @@ func TestModule_Configure(t *testing.T) {
 		t.Error(err)
 	}
 }
+
+func TestModule_Configure_InvalidConfiguration(t *testing.T) {
+	t.Parallel()
+
+	invalid := config.Map{
+		"flamingo.opentelemetry.otlp.http.enable":   true,
+		"flamingo.opentelemetry.otlp.http.endpoint": "localhost:4318",
+		"flamingo.opentelemetry.propagators":        config.Slice{"tracecontext", "unknown"},
+	}
+
+	t.Run("fail with all problems", func(t *testing.T) {
+		t.Parallel()
+
+		err := config.TryModules(invalid, new(loggerModule), new(opentelemetry.Module))
+
+		assert.ErrorContains(t, err, "flamingo.opentelemetry.otlp.http.endpoint")
+		assert.ErrorContains(t, err, `unknown propagator "unknown"`)
+	})
+
+	t.Run("warn about sampler entries which never match", func(t *testing.T) {
+		t.Parallel()
+
+		static := config.Map{"flamingo.opentelemetry.tracing.sampler.allowlist": config.Slice{"static"}}
+
+		assert.NoError(t, config.TryModules(static, new(loggerModule), new(opentelemetry.Module)))
+	})
+
+	t.Run("continue in lenient mode", func(t *testing.T) {
+		t.Parallel()
+
+		lenient := config.Map{"flamingo.opentelemetry.lenient": true}
+		for k, v := range invalid {
+			lenient[k] = v
+		}
+
+		assert.NoError(t, config.TryModules(lenient, new(loggerModule), new(opentelemetry.Module)))
+	})
+}
+
+//nolint:paralleltest // replaces http.DefaultTransport
+func TestModule_Configure_InstrumentDefaultTransport(t *testing.T) {
+	original := http.DefaultTransport
+
+	t.Cleanup(func() { http.DefaultTransport = original })
+
+	assert.NoError(t, config.TryModules(nil, new(loggerModule), new(opentelemetry.Module)))
+	assert.Same(t, original, http.DefaultTransport, "not instrumented by default")
+
+	instrument := config.Map{"flamingo.opentelemetry.instrumentDefaultTransport": true}
+
+	assert.NoError(t, config.TryModules(instrument, new(loggerModule), new(opentelemetry.Module)))
+
+	instrumented := http.DefaultTransport
+	assert.NotSame(t, original, instrumented)
+
+	assert.NoError(t, config.TryModules(instrument, new(loggerModule), new(opentelemetry.Module)))
+	assert.Same(t, instrumented, http.DefaultTransport, "repeated configuration does not wrap again")
+}
+
+func TestModule_Configure_OTLPExporters(t *testing.T) {
+	t.Parallel()
+
+	collector := opentelemetrytest.NewCollector(t)
+	providers := new(providerModule)
+
+	require.NoError(t, config.TryModules(config.Map{
+		"flamingo.opentelemetry.setGlobals": false,
+		"flamingo.opentelemetry.otlp.http": config.Map{
+			"enable":   true,
+			"endpoint": collector.HTTPEndpoint() + "/v1/traces",
+			"headers":  config.Map{"X-Tenant": "shop"},
+		},
+		"flamingo.opentelemetry.otlp.grpc": config.Map{
+			"enable":   true,
+			"endpoint": "http://" + collector.GRPCEndpoint(),
+			"headers":  config.Map{"X-Tenant": "shop"},
+		},
+	}, new(loggerModule), providers))
+
+	// the URL sampler only samples root spans of requests
+	_, span := providers.tracerProvider.Tracer("test").Start(context.Background(), "checkout", trace.WithAttributes(
+		attribute.String("url.path", "/checkout"),
+		attribute.String("cart", "4711"),
+	))
+	span.End()
+
+	flusher, ok := providers.tracerProvider.(interface {
+		ForceFlush(ctx context.Context) error
+	})
+	require.True(t, ok)
+	require.NoError(t, flusher.ForceFlush(context.Background()))
+
+	assert.Len(t, collector.WaitForSpans(t, 2), 2, "one span per exporter")
+	collector.AssertSpan(t, "checkout", attribute.String("cart", "4711"))
+	collector.AssertHeader(t, "X-Tenant", "shop")
+
+	protocols := make([]string, 0, 2)
+	for _, request := range collector.Requests() {
+		protocols = append(protocols, request.Protocol)
+	}
+
+	assert.ElementsMatch(t, []string{opentelemetrytest.ProtocolHTTP, opentelemetrytest.ProtocolGRPC}, protocols)
+}
+
+func TestModule_Configure_OTLPExportersWithTLS(t *testing.T) {
+	t.Parallel()
+
+	collector := opentelemetrytest.NewCollector(t, opentelemetrytest.WithTLS())
+	providers := new(providerModule)
+
+	certificate := filepath.Join(t.TempDir(), "collector.pem")
+	require.NoError(t, os.WriteFile(certificate,
+		pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: collector.Certificate().Raw}), 0o600))
+
+	require.NoError(t, config.TryModules(config.Map{
+		"flamingo.opentelemetry.setGlobals": false,
+		"flamingo.opentelemetry.otlp.http": config.Map{
+			"enable":   true,
+			"endpoint": collector.HTTPEndpoint() + "/v1/traces",
+		},
+		"flamingo.opentelemetry.otlp.grpc": config.Map{
+			"enable":   true,
+			"endpoint": "https://" + collector.GRPCEndpoint(),
+		},
+		"flamingo.opentelemetry.exporterTransport": config.Map{
+			"certificate": certificate,
+		},
+	}, new(loggerModule), providers))
+
+	_, span := providers.tracerProvider.Tracer("test").Start(context.Background(), "checkout", trace.WithAttributes(
+		attribute.String("url.path", "/checkout"),
+	))
+	span.End()
+
+	flusher, ok := providers.tracerProvider.(interface {
+		ForceFlush(ctx context.Context) error
+	})
+	require.True(t, ok)
+	require.NoError(t, flusher.ForceFlush(context.Background()))
+
+	assert.Len(t, collector.WaitForSpans(t, 2), 2, "both exporters trust the CA of the collector")
+}
@@ -0,0 +1,552 @@
+package opentelemetrytest
+
+import (
+	"compress/gzip"
+	"context"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	collogspb "go.opentelemetry.io/proto/otlp/collector/logs/v1"
+	colmetricspb "go.opentelemetry.io/proto/otlp/collector/metrics/v1"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	logspb "go.opentelemetry.io/proto/otlp/logs/v1"
+	metricspb "go.opentelemetry.io/proto/otlp/metrics/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	_ "google.golang.org/grpc/encoding/gzip" // registers the gzip compressor used by the exporters
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/protobuf/encoding/protojson"
+	"google.golang.org/protobuf/proto"
+)
+
+// Protocols and signals of the received requests
+const (
+	ProtocolHTTP = "http"
+	ProtocolGRPC = "grpc"
+
+	SignalTraces  = "traces"
+	SignalMetrics = "metrics"
+	SignalLogs    = "logs"
+)
+
+const (
+	contentTypeProtobuf = "application/x-protobuf"
+	contentTypeJSON     = "application/json"
+
+	// waitTimeout limits WaitForSpans, the batch span processor exports every 5 seconds by default
+	waitTimeout  = 10 * time.Second
+	pollInterval = 10 * time.Millisecond
+)
+
+var (
+	errUnsupportedContentType = errors.New("unsupported content type")
+
+	// otlpIDFields are hex encoded in OTLP/JSON instead of the base64 default of protojson
+	otlpIDFields = map[string]bool{"traceId": true, "spanId": true, "parentSpanId": true}
+)
+
+type (
+	// Collector receives OTLP over HTTP and gRPC in the test process and records the exported telemetry.
+	// The HTTP receiver accepts protobuf and OTLP/JSON on the paths /v1/traces, /v1/metrics and /v1/logs,
+	// both receivers accept gzip compressed requests.
+	Collector struct {
+		http     *httptest.Server
+		grpc     *grpc.Server
+		grpcAddr string
+
+		mu       sync.Mutex
+		requests []Request
+	}
+
+	// Request is one received export, gRPC metadata is recorded as Header
+	Request struct {
+		Protocol        string
+		Signal          string
+		Header          http.Header
+		ResourceSpans   []*tracepb.ResourceSpans
+		ResourceMetrics []*metricspb.ResourceMetrics
+		ResourceLogs    []*logspb.ResourceLogs
+	}
+
+	// CollectorOption configures the collector
+	CollectorOption func(*collectorOptions)
+
+	collectorOptions struct {
+		tls bool
+	}
+
+	traceService struct {
+		coltracepb.UnimplementedTraceServiceServer
+		collector *Collector
+	}
+
+	metricsService struct {
+		colmetricspb.UnimplementedMetricsServiceServer
+		collector *Collector
+	}
+
+	logsService struct {
+		collogspb.UnimplementedLogsServiceServer
+		collector *Collector
+	}
+)
+
+// WithTLS serves both receivers with the self-signed certificate of httptest, see CertPool
+func WithTLS() CollectorOption {
+	return func(o *collectorOptions) {
+		o.tls = true
+	}
+}
+
+// NewCollector starts the HTTP and the gRPC receiver on local ports, they are stopped when the test ends
+func NewCollector(t testing.TB, options ...CollectorOption) *Collector {
+	t.Helper()
+
+	var opts collectorOptions
+	for _, option := range options {
+		option(&opts)
+	}
+
+	c := new(Collector)
+
+	mux := http.NewServeMux()
+	mux.Handle("POST /v1/traces", c.receiveHTTP(
+		func() proto.Message { return new(coltracepb.ExportTraceServiceRequest) },
+		new(coltracepb.ExportTraceServiceResponse),
+	))
+	mux.Handle("POST /v1/metrics", c.receiveHTTP(
+		func() proto.Message { return new(colmetricspb.ExportMetricsServiceRequest) },
+		new(colmetricspb.ExportMetricsServiceResponse),
+	))
+	mux.Handle("POST /v1/logs", c.receiveHTTP(
+		func() proto.Message { return new(collogspb.ExportLogsServiceRequest) },
+		new(collogspb.ExportLogsServiceResponse),
+	))
+
+	c.http = httptest.NewUnstartedServer(mux)
+
+	var serverOptions []grpc.ServerOption
+
+	if opts.tls {
+		c.http.StartTLS()
+
+		serverOptions = append(serverOptions, grpc.Creds(credentials.NewServerTLSFromCert(&c.http.TLS.Certificates[0])))
+	} else {
+		c.http.Start()
+	}
+
+	t.Cleanup(c.http.Close)
+
+	listener, err := (&net.ListenConfig{}).Listen(context.Background(), "tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatalf("failed to listen for OTLP gRPC: %v", err)
+	}
+
+	c.grpcAddr = listener.Addr().String()
+	c.grpc = grpc.NewServer(serverOptions...)
+	coltracepb.RegisterTraceServiceServer(c.grpc, &traceService{collector: c})
+	colmetricspb.RegisterMetricsServiceServer(c.grpc, &metricsService{collector: c})
+	collogspb.RegisterLogsServiceServer(c.grpc, &logsService{collector: c})
+
+	go func() {
+		_ = c.grpc.Serve(listener)
+	}()
+
+	t.Cleanup(c.grpc.Stop)
+
+	return c
+}
+
+// HTTPEndpoint returns the base URL of the HTTP receiver, e.g. http://127.0.0.1:4711
+func (c *Collector) HTTPEndpoint() string {
+	return c.http.URL
+}
+
+// GRPCEndpoint returns the host and port of the gRPC receiver
+func (c *Collector) GRPCEndpoint() string {
+	return c.grpcAddr
+}
+
+// CertPool trusts the certificate of the receivers, it is nil without TLS
+func (c *Collector) CertPool() *x509.CertPool {
+	if c.http.Certificate() == nil {
+		return nil
+	}
+
+	pool := x509.NewCertPool()
+	pool.AddCert(c.http.Certificate())
+
+	return pool
+}
+
+// Certificate returns the certificate of the receivers, e.g. to write it to a CA file, it is nil without TLS
+func (c *Collector) Certificate() *x509.Certificate {
+	return c.http.Certificate()
+}
+
+// Requests returns the received exports in the order they arrived
+func (c *Collector) Requests() []Request {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return append([]Request(nil), c.requests...)
+}
+
+// Reset drops the received exports
+func (c *Collector) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = nil
+}
+
+// Spans returns all received spans
+func (c *Collector) Spans() []*tracepb.Span {
+	var spans []*tracepb.Span
+
+	for _, request := range c.Requests() {
+		for _, resource := range request.ResourceSpans {
+			for _, scope := range resource.GetScopeSpans() {
+				spans = append(spans, scope.GetSpans()...)
+			}
+		}
+	}
+
+	return spans
+}
+
+// Metrics returns all received metrics, a metric is contained once per export
+func (c *Collector) Metrics() []*metricspb.Metric {
+	var metrics []*metricspb.Metric
+
+	for _, request := range c.Requests() {
+		for _, resource := range request.ResourceMetrics {
+			for _, scope := range resource.GetScopeMetrics() {
+				metrics = append(metrics, scope.GetMetrics()...)
+			}
+		}
+	}
+
+	return metrics
+}
+
+// LogRecords returns all received log records
+func (c *Collector) LogRecords() []*logspb.LogRecord {
+	var records []*logspb.LogRecord
+
+	for _, request := range c.Requests() {
+		for _, resource := range request.ResourceLogs {
+			for _, scope := range resource.GetScopeLogs() {
+				records = append(records, scope.GetLogRecords()...)
+			}
+		}
+	}
+
+	return records
+}
+
+// WaitForSpans waits until at least count spans were received, the exporters send them asynchronously
+func (c *Collector) WaitForSpans(t testing.TB, count int) []*tracepb.Span {
+	t.Helper()
+
+	deadline := time.Now().Add(waitTimeout)
+
+	for {
+		spans := c.Spans()
+		if len(spans) >= count {
+			return spans
+		}
+
+		if time.Now().After(deadline) {
+			t.Errorf("received %d of %d spans within %s", len(spans), count, waitTimeout)
+
+			return spans
+		}
+
+		time.Sleep(pollInterval)
+	}
+}
+
+// AssertSpan asserts that a span with the name and at least the given attributes was received and returns the first one
+func (c *Collector) AssertSpan(t testing.TB, name string, attrs ...attribute.KeyValue) (*tracepb.Span, bool) {
+	t.Helper()
+
+	var names []string
+
+	for _, span := range c.Spans() {
+		if span.GetName() == name && hasAnyValues(span.GetAttributes(), attrs) {
+			return span, true
+		}
+
+		names = append(names, span.GetName())
+	}
+
+	t.Errorf("no span %q with the attributes %v was received, received spans: %q", name, attrs, names)
+
+	return nil, false
+}
+
+// AssertHeader asserts that every received export carried the header, gRPC metadata is checked the same way
+func (c *Collector) AssertHeader(t testing.TB, name string, value string) bool {
+	t.Helper()
+
+	requests := c.Requests()
+	if len(requests) == 0 {
+		t.Errorf("no export was received")
+
+		return false
+	}
+
+	for _, request := range requests {
+		if got := request.Header.Get(name); got != value {
+			t.Errorf("%s %s export has the header %s %q instead of %q", request.Protocol, request.Signal, name, got, value)
+
+			return false
+		}
+	}
+
+	return true
+}
+
+// FindMetric returns the last received data of the metric with the given name
+func (c *Collector) FindMetric(name string) (*metricspb.Metric, error) {
+	metrics := c.Metrics()
+
+	for i := len(metrics) - 1; i >= 0; i-- {
+		if metrics[i].GetName() == name {
+			return metrics[i], nil
+		}
+	}
+
+	return nil, fmt.Errorf("%w: %s", ErrMetricNotFound, name)
+}
+
+func (c *Collector) record(protocol string, header http.Header, message proto.Message) {
+	request := Request{Protocol: protocol, Header: header}
+
+	switch m := message.(type) {
+	case *coltracepb.ExportTraceServiceRequest:
+		request.Signal = SignalTraces
+		request.ResourceSpans = m.GetResourceSpans()
+	case *colmetricspb.ExportMetricsServiceRequest:
+		request.Signal = SignalMetrics
+		request.ResourceMetrics = m.GetResourceMetrics()
+	case *collogspb.ExportLogsServiceRequest:
+		request.Signal = SignalLogs
+		request.ResourceLogs = m.GetResourceLogs()
+	}
+
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.requests = append(c.requests, request)
+}
+
+// receiveHTTP decodes the export request in the encoding it was sent and answers in the same encoding
+func (c *Collector) receiveHTTP(newRequest func() proto.Message, response proto.Message) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := io.Reader(r.Body)
+
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+
+				return
+			}
+
+			body = reader
+		}
+
+		raw, err := io.ReadAll(body)
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		contentType := r.Header.Get("Content-Type")
+		message := newRequest()
+
+		var encoded []byte
+
+		switch contentType {
+		case contentTypeProtobuf:
+			if err = proto.Unmarshal(raw, message); err == nil {
+				encoded, err = proto.Marshal(response)
+			}
+		case contentTypeJSON:
+			if err = unmarshalOTLPJSON(raw, message); err == nil {
+				encoded, err = protojson.Marshal(response)
+			}
+		default:
+			err = fmt.Errorf("%w %q", errUnsupportedContentType, contentType)
+		}
+
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+
+			return
+		}
+
+		c.record(ProtocolHTTP, r.Header.Clone(), message)
+
+		w.Header().Set("Content-Type", contentType)
+		_, _ = w.Write(encoded)
+	})
+}
+
+func (s *traceService) Export(ctx context.Context, request *coltracepb.ExportTraceServiceRequest) (*coltracepb.ExportTraceServiceResponse, error) {
+	s.collector.record(ProtocolGRPC, metadataHeader(ctx), request)
+
+	return new(coltracepb.ExportTraceServiceResponse), nil
+}
+
+func (s *metricsService) Export(ctx context.Context, request *colmetricspb.ExportMetricsServiceRequest) (*colmetricspb.ExportMetricsServiceResponse, error) {
+	s.collector.record(ProtocolGRPC, metadataHeader(ctx), request)
+
+	return new(colmetricspb.ExportMetricsServiceResponse), nil
+}
+
+func (s *logsService) Export(ctx context.Context, request *collogspb.ExportLogsServiceRequest) (*collogspb.ExportLogsServiceResponse, error) {
+	s.collector.record(ProtocolGRPC, metadataHeader(ctx), request)
+
+	return new(collogspb.ExportLogsServiceResponse), nil
+}
+
+// metadataHeader converts the incoming gRPC metadata, the keys are canonicalized like HTTP headers
+func metadataHeader(ctx context.Context) http.Header {
+	md, _ := metadata.FromIncomingContext(ctx)
+	header := make(http.Header, len(md))
+
+	for key, values := range md {
+		for _, value := range values {
+			header.Add(key, value)
+		}
+	}
+
+	return header
+}
+
+// unmarshalOTLPJSON decodes OTLP/JSON, whose trace and span IDs are hex instead of base64 encoded
+func unmarshalOTLPJSON(raw []byte, message proto.Message) error {
+	var document any
+	if err := json.Unmarshal(raw, &document); err != nil {
+		return fmt.Errorf("failed to decode OTLP/JSON: %w", err)
+	}
+
+	if err := base64IDs(document); err != nil {
+		return err
+	}
+
+	raw, err := json.Marshal(document)
+	if err != nil {
+		return fmt.Errorf("failed to decode OTLP/JSON: %w", err)
+	}
+
+	if err := protojson.Unmarshal(raw, message); err != nil {
+		return fmt.Errorf("failed to decode OTLP/JSON: %w", err)
+	}
+
+	return nil
+}
+
+// base64IDs replaces the hex encoded trace and span IDs with their base64 representation
+func base64IDs(node any) error {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, value := range v {
+			id, ok := value.(string)
+			if !otlpIDFields[key] || !ok {
+				if err := base64IDs(value); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			raw, err := hex.DecodeString(id)
+			if err != nil {
+				return fmt.Errorf("failed to decode OTLP/JSON %s: %w", key, err)
+			}
+
+			v[key] = base64.StdEncoding.EncodeToString(raw)
+		}
+	case []any:
+		for _, value := range v {
+			if err := base64IDs(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
+
+func hasAnyValues(attrs []*commonpb.KeyValue, expected []attribute.KeyValue) bool {
+	for _, want := range expected {
+		found := false
+
+		for _, attr := range attrs {
+			if attr.GetKey() == string(want.Key) && proto.Equal(attr.GetValue(), anyValue(want.Value)) {
+				found = true
+
+				break
+			}
+		}
+
+		if !found {
+			return false
+		}
+	}
+
+	return true
+}
+
+// anyValue converts the attribute value like the OTLP exporters do
+func anyValue(value attribute.Value) *commonpb.AnyValue {
+	switch value.Type() {
+	case attribute.BOOL:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_BoolValue{BoolValue: value.AsBool()}}
+	case attribute.INT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_IntValue{IntValue: value.AsInt64()}}
+	case attribute.FLOAT64:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_DoubleValue{DoubleValue: value.AsFloat64()}}
+	case attribute.STRING:
+		return &commonpb.AnyValue{Value: &commonpb.AnyValue_StringValue{StringValue: value.AsString()}}
+	case attribute.BOOLSLICE:
+		return arrayValue(value.AsBoolSlice(), attribute.BoolValue)
+	case attribute.INT64SLICE:
+		return arrayValue(value.AsInt64Slice(), attribute.Int64Value)
+	case attribute.FLOAT64SLICE:
+		return arrayValue(value.AsFloat64Slice(), attribute.Float64Value)
+	case attribute.STRINGSLICE:
+		return arrayValue(value.AsStringSlice(), attribute.StringValue)
+	case attribute.EMPTY:
+	}
+
+	return new(commonpb.AnyValue)
+}
+
+func arrayValue[T any](values []T, convert func(T) attribute.Value) *commonpb.AnyValue {
+	array := &commonpb.ArrayValue{Values: make([]*commonpb.AnyValue, 0, len(values))}
+	for _, value := range values {
+		array.Values = append(array.Values, anyValue(convert(value)))
+	}
+
+	return &commonpb.AnyValue{Value: &commonpb.AnyValue_ArrayValue{ArrayValue: array}}
+}
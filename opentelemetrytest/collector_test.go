@@ -0,0 +1,107 @@
+package opentelemetrytest_test
+
+import (
+	"bytes"
+	"context"
+	"crypto/tls"
+	"encoding/hex"
+	"net/http"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"google.golang.org/grpc/credentials"
+
+	"flamingo.me/opentelemetry/opentelemetrytest"
+)
+
+func TestCollector_TLS(t *testing.T) {
+	t.Parallel()
+
+	collector := opentelemetrytest.NewCollector(t, opentelemetrytest.WithTLS())
+	tlsConfig := &tls.Config{RootCAs: collector.CertPool(), MinVersion: tls.VersionTLS12}
+
+	httpExporter, err := otlptracehttp.New(context.Background(),
+		otlptracehttp.WithEndpointURL(collector.HTTPEndpoint()+"/v1/traces"),
+		otlptracehttp.WithTLSClientConfig(tlsConfig),
+		otlptracehttp.WithCompression(otlptracehttp.GzipCompression),
+		otlptracehttp.WithHeaders(map[string]string{"Authorization": "Bearer token"}),
+	)
+	require.NoError(t, err)
+
+	grpcExporter, err := otlptracegrpc.New(context.Background(),
+		otlptracegrpc.WithEndpoint(collector.GRPCEndpoint()),
+		otlptracegrpc.WithTLSCredentials(credentials.NewTLS(tlsConfig)),
+		otlptracegrpc.WithCompressor("gzip"),
+		otlptracegrpc.WithHeaders(map[string]string{"Authorization": "Bearer token"}),
+	)
+	require.NoError(t, err)
+
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(httpExporter), tracesdk.WithSyncer(grpcExporter))
+	_, span := tp.Tracer("test").Start(context.Background(), "checkout")
+	span.SetAttributes(attribute.StringSlice("items", []string{"shirt", "shoes"}), attribute.Int("amount", 2))
+	span.End()
+
+	require.NoError(t, tp.Shutdown(context.Background()))
+
+	assert.Len(t, collector.Spans(), 2)
+	collector.AssertSpan(t, "checkout", attribute.StringSlice("items", []string{"shirt", "shoes"}), attribute.Int("amount", 2))
+	collector.AssertHeader(t, "Authorization", "Bearer token")
+
+	collector.Reset()
+	assert.Empty(t, collector.Requests())
+}
+
+func TestCollector_JSON(t *testing.T) {
+	t.Parallel()
+
+	collector := opentelemetrytest.NewCollector(t)
+
+	post := func(path string, body string) *http.Response {
+		t.Helper()
+
+		req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, collector.HTTPEndpoint()+path, bytes.NewBufferString(body))
+		require.NoError(t, err)
+		req.Header.Set("Content-Type", "application/json")
+
+		resp, err := http.DefaultClient.Do(req)
+		require.NoError(t, err)
+		require.NoError(t, resp.Body.Close())
+
+		return resp
+	}
+
+	resp := post("/v1/traces", `{"resourceSpans":[{"scopeSpans":[{"spans":[{
+		"traceId":"5b8efff798038103d269b633813fc60c","spanId":"eee19b7ec3c1b174","name":"import"
+	}]}]}]}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = post("/v1/metrics", `{"resourceMetrics":[{"scopeMetrics":[{"metrics":[{
+		"name":"imported","sum":{"dataPoints":[{"asInt":"3"}]}
+	}]}]}]}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = post("/v1/logs", `{"resourceLogs":[{"scopeLogs":[{"logRecords":[{"body":{"stringValue":"imported"}}]}]}]}`)
+	assert.Equal(t, http.StatusOK, resp.StatusCode)
+
+	resp = post("/v1/logs", `{"resourceLogs":`)
+	assert.Equal(t, http.StatusBadRequest, resp.StatusCode)
+
+	span, ok := collector.AssertSpan(t, "import")
+	require.True(t, ok)
+	assert.Equal(t, "5b8efff798038103d269b633813fc60c", hex.EncodeToString(span.GetTraceId()), "hex IDs are decoded")
+
+	metric, err := collector.FindMetric("imported")
+	require.NoError(t, err)
+	assert.Equal(t, int64(3), metric.GetSum().GetDataPoints()[0].GetAsInt())
+
+	require.Len(t, collector.LogRecords(), 1)
+	assert.Equal(t, "imported", collector.LogRecords()[0].GetBody().GetStringValue())
+
+	_, err = collector.FindMetric("exported")
+	require.ErrorIs(t, err, opentelemetrytest.ErrMetricNotFound)
+}
@@ -0,0 +1,159 @@
+// Package opentelemetrytest replaces the providers of the opentelemetry module by in-memory ones, so tests can
+// assert on the spans and metrics of the instrumented code
+package opentelemetrytest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"testing"
+
+	"flamingo.me/dingo"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/metric"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	"go.opentelemetry.io/otel/sdk/metric/metricdata"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/opentelemetry"
+)
+
+// ErrMetricNotFound is returned by FindMetric if no metric with the name was recorded
+var ErrMetricNotFound = errors.New("metric not found")
+
+type (
+	// Module overrides the tracer and meter provider bound by the opentelemetry module, the exporters, readers and
+	// runtime metrics configured for the application are not started at all.
+	// Spans are exported synchronously when they end and every span is sampled, so tests are deterministic.
+	Module struct {
+		Exporter       *tracetest.InMemoryExporter
+		Reader         *sdkMetric.ManualReader
+		TracerProvider *tracesdk.TracerProvider
+		MeterProvider  *sdkMetric.MeterProvider
+	}
+)
+
+var _ dingo.Depender = (*Module)(nil)
+
+// NewModule creates the in-memory providers, a zero Module creates them on Configure
+func NewModule() *Module {
+	m := new(Module)
+	m.init()
+
+	return m
+}
+
+func (m *Module) init() {
+	m.Exporter = tracetest.NewInMemoryExporter()
+	m.Reader = sdkMetric.NewManualReader()
+	m.TracerProvider = tracesdk.NewTracerProvider(
+		tracesdk.WithSampler(tracesdk.AlwaysSample()),
+		// the simple span processor exports within span.End
+		tracesdk.WithSyncer(m.Exporter),
+	)
+	m.MeterProvider = sdkMetric.NewMeterProvider(sdkMetric.WithReader(m.Reader))
+}
+
+// Configure DI
+func (m *Module) Configure(injector *dingo.Injector) {
+	if m.TracerProvider == nil {
+		m.init()
+	}
+
+	injector.Override(new(trace.TracerProvider), "").ToInstance(m.TracerProvider)
+	injector.Override(new(metric.MeterProvider), "").ToInstance(m.MeterProvider)
+}
+
+// CueConfig switches off the export of the opentelemetry module, nothing leaves the test process
+func (m *Module) CueConfig() string {
+	return `flamingo: opentelemetry: disableExport: true`
+}
+
+// Depends makes sure the providers of the opentelemetry module are bound before they are overridden
+func (m *Module) Depends() []dingo.Module {
+	return []dingo.Module{
+		new(opentelemetry.Module),
+	}
+}
+
+// Reset drops the recorded spans, metrics are cumulative and kept
+func (m *Module) Reset() {
+	m.Exporter.Reset()
+}
+
+// Spans returns the ended spans with the given name in the order they ended
+func (m *Module) Spans(name string) tracetest.SpanStubs {
+	var spans tracetest.SpanStubs
+
+	for _, span := range m.Exporter.GetSpans() {
+		if span.Name == name {
+			spans = append(spans, span)
+		}
+	}
+
+	return spans
+}
+
+// AssertSpan asserts that a span with the name and at least the given attributes ended and returns the first one
+func (m *Module) AssertSpan(t testing.TB, name string, attrs ...attribute.KeyValue) (tracetest.SpanStub, bool) {
+	t.Helper()
+
+	spans := m.Spans(name)
+
+	for _, span := range spans {
+		if hasAttributes(span.Attributes, attrs) {
+			return span, true
+		}
+	}
+
+	if len(spans) == 0 {
+		names := make([]string, 0, len(m.Exporter.GetSpans()))
+		for _, span := range m.Exporter.GetSpans() {
+			names = append(names, span.Name)
+		}
+
+		t.Errorf("no span %q ended, ended spans: %q", name, names)
+	} else {
+		for _, span := range spans {
+			t.Logf("span %q has the attributes %v", name, span.Attributes)
+		}
+
+		t.Errorf("no span %q has the attributes %v", name, attrs)
+	}
+
+	return tracetest.SpanStub{}, false
+}
+
+// FindMetric collects the metrics and returns the one with the given name
+func (m *Module) FindMetric(name string) (metricdata.Metrics, error) {
+	var rm metricdata.ResourceMetrics
+
+	if err := m.Reader.Collect(context.Background(), &rm); err != nil {
+		return metricdata.Metrics{}, fmt.Errorf("failed to collect metrics: %w", err)
+	}
+
+	for _, scope := range rm.ScopeMetrics {
+		for _, found := range scope.Metrics {
+			if found.Name == name {
+				return found, nil
+			}
+		}
+	}
+
+	return metricdata.Metrics{}, fmt.Errorf("%w: %s", ErrMetricNotFound, name)
+}
+
+func hasAttributes(attrs []attribute.KeyValue, expected []attribute.KeyValue) bool {
+	set := attribute.NewSet(attrs...)
+
+	for _, attr := range expected {
+		value, ok := set.Value(attr.Key)
+		if !ok || value != attr.Value {
+			return false
+		}
+	}
+
+	return true
+}
@@ -0,0 +1,100 @@
+package opentelemetrytest_test
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"flamingo.me/dingo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/config"
+	"flamingo.me/flamingo/v3/framework/flamingo"
+	flamingoHttp "flamingo.me/flamingo/v3/framework/http"
+
+	"flamingo.me/opentelemetry/opentelemetrytest"
+)
+
+type (
+	loggerModule struct{}
+
+	// probeModule is configured after the test module and receives the instrumentation bound by then
+	probeModule struct {
+		recorder       *opentelemetrytest.Module
+		tracerProvider trace.TracerProvider
+		wrapper        flamingoHttp.HandlerWrapper
+	}
+)
+
+// Configure DI
+func (m *loggerModule) Configure(injector *dingo.Injector) {
+	injector.Bind(new(flamingo.Logger)).To(new(flamingo.NullLogger))
+}
+
+// Inject dependencies
+func (m *probeModule) Inject(tracerProvider trace.TracerProvider, wrapper flamingoHttp.HandlerWrapper) *probeModule {
+	m.tracerProvider = tracerProvider
+	m.wrapper = wrapper
+
+	return m
+}
+
+// Configure DI
+func (m *probeModule) Configure(*dingo.Injector) {}
+
+func (m *probeModule) Depends() []dingo.Module {
+	return []dingo.Module{m.recorder}
+}
+
+func TestModule(t *testing.T) {
+	t.Parallel()
+
+	recorder := opentelemetrytest.NewModule()
+	probe := &probeModule{recorder: recorder}
+
+	require.NoError(t, config.TryModules(
+		config.Map{"flamingo.opentelemetry.setGlobals": false},
+		new(loggerModule),
+		probe,
+	))
+	assert.Same(t, recorder.TracerProvider, probe.tracerProvider)
+
+	handler := probe.wrapper(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusNoContent)
+	}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/products", nil))
+
+	span, ok := recorder.AssertSpan(t, "incoming request", attribute.String("http.request.method", http.MethodGet))
+	require.True(t, ok)
+	assert.Contains(t, span.Attributes, attribute.Int("http.response.status_code", http.StatusNoContent))
+
+	duration, err := recorder.FindMetric("http.server.request.duration")
+	require.NoError(t, err)
+	assert.Equal(t, "s", duration.Unit)
+
+	_, err = recorder.FindMetric("unknown")
+	require.ErrorIs(t, err, opentelemetrytest.ErrMetricNotFound)
+
+	recorder.Reset()
+	assert.Empty(t, recorder.Spans("incoming request"))
+}
+
+func TestModule_DisablesExport(t *testing.T) {
+	t.Parallel()
+
+	// neither the exporter nor the stdout output are created, so their invalid settings do not fail the module
+	require.NoError(t, config.TryModules(
+		config.Map{
+			"flamingo.opentelemetry.setGlobals":      false,
+			"flamingo.opentelemetry.zipkin.enable":   true,
+			"flamingo.opentelemetry.zipkin.endpoint": "localhost:9411",
+			"flamingo.opentelemetry.stdout.traces":   true,
+			"flamingo.opentelemetry.stdout.format":   "xml",
+		},
+		new(loggerModule),
+		opentelemetrytest.NewModule(),
+	))
+}
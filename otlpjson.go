@@ -0,0 +1,172 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"slices"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+var errOTLPStatus = errors.New("collector rejected the spans")
+
+// otlpIDFields are encoded as hex strings in OTLP/JSON instead of the base64 default of protojson
+var otlpIDFields = map[string]bool{"traceId": true, "spanId": true, "parentSpanId": true}
+
+// retryableStatus are the responses of an overloaded or unavailable collector, see the OTLP specification
+var retryableStatus = []int{
+	http.StatusTooManyRequests,
+	http.StatusBadGateway,
+	http.StatusServiceUnavailable,
+	http.StatusGatewayTimeout,
+}
+
+// otlpJSONClient uploads traces as OTLP/JSON, which is not supported by the otlptracehttp exporter
+type otlpJSONClient struct {
+	endpoint string
+	headers  map[string]string
+	gzip     bool
+	policy   exportPolicy
+	client   *http.Client
+}
+
+var _ otlptrace.Client = (*otlpJSONClient)(nil)
+
+func newOTLPJSONClient(cfg exporterConfig, endpoint *url.URL, policy exportPolicy) *otlpJSONClient {
+	return &otlpJSONClient{
+		endpoint: endpoint.String(),
+		headers:  cfg.Headers,
+		gzip:     cfg.Compression == compressionGzip,
+		policy:   policy,
+		client:   cfg.httpClient(0),
+	}
+}
+
+func (*otlpJSONClient) Start(context.Context) error { return nil }
+
+func (*otlpJSONClient) Stop(context.Context) error { return nil }
+
+func (c *otlpJSONClient) UploadTraces(ctx context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	body, err := marshalOTLPJSON(&coltracepb.ExportTraceServiceRequest{ResourceSpans: protoSpans})
+	if err != nil {
+		return err
+	}
+
+	if c.gzip {
+		var compressed bytes.Buffer
+
+		w := gzip.NewWriter(&compressed)
+		_, _ = w.Write(body) // writing to a buffer does not fail
+		_ = w.Close()
+
+		body = compressed.Bytes()
+	}
+
+	return c.policy.do(ctx, func(ctx context.Context) error {
+		return c.send(ctx, body)
+	})
+}
+
+// send posts the encoded spans once, network errors and overload responses are retryable
+func (c *otlpJSONClient) send(ctx context.Context, body []byte) error {
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, c.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create OTLP request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	if c.gzip {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+
+	for key, value := range c.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := c.client.Do(req)
+	if err != nil {
+		return &retryableError{err: fmt.Errorf("failed to send spans to %s: %w", c.endpoint, err)}
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	switch {
+	case resp.StatusCode >= 200 && resp.StatusCode < 300:
+		return nil
+	case slices.Contains(retryableStatus, resp.StatusCode):
+		return &retryableError{err: fmt.Errorf("%w: %s responded %s", errOTLPStatus, c.endpoint, resp.Status)}
+	}
+
+	return fmt.Errorf("%w: %s responded %s", errOTLPStatus, c.endpoint, resp.Status)
+}
+
+// marshalOTLPJSON encodes the request following the OTLP/JSON rules: enums as numbers and IDs as hex strings
+func marshalOTLPJSON(request *coltracepb.ExportTraceServiceRequest) ([]byte, error) {
+	encoded, err := protojson.MarshalOptions{UseEnumNumbers: true}.Marshal(request)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OTLP/JSON: %w", err)
+	}
+
+	var document any
+	if err := json.Unmarshal(encoded, &document); err != nil {
+		return nil, fmt.Errorf("failed to encode OTLP/JSON: %w", err)
+	}
+
+	if err := hexIDs(document); err != nil {
+		return nil, err
+	}
+
+	encoded, err = json.Marshal(document)
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode OTLP/JSON: %w", err)
+	}
+
+	return encoded, nil
+}
+
+// hexIDs replaces the base64 encoded trace and span IDs with their hex representation
+func hexIDs(node any) error {
+	switch v := node.(type) {
+	case map[string]any:
+		for key, value := range v {
+			id, ok := value.(string)
+			if !otlpIDFields[key] || !ok {
+				if err := hexIDs(value); err != nil {
+					return err
+				}
+
+				continue
+			}
+
+			raw, err := base64.StdEncoding.DecodeString(id)
+			if err != nil {
+				return fmt.Errorf("failed to encode OTLP/JSON %s: %w", key, err)
+			}
+
+			v[key] = hex.EncodeToString(raw)
+		}
+	case []any:
+		for _, value := range v {
+			if err := hexIDs(value); err != nil {
+				return err
+			}
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,80 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private OTLP/JSON client
+
+import (
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestOTLPHTTPExporter_JSON(t *testing.T) {
+	t.Parallel()
+
+	type request struct {
+		header http.Header
+		body   map[string]any
+	}
+
+	requests := make(chan request, 1)
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body := r.Body
+		if r.Header.Get("Content-Encoding") == "gzip" {
+			reader, err := gzip.NewReader(r.Body)
+			if err != nil {
+				w.WriteHeader(http.StatusBadRequest)
+
+				return
+			}
+
+			body = reader
+		}
+
+		raw, _ := io.ReadAll(body)
+
+		var decoded map[string]any
+		if err := json.Unmarshal(raw, &decoded); err != nil {
+			w.WriteHeader(http.StatusBadRequest)
+
+			return
+		}
+
+		requests <- request{header: r.Header.Clone(), body: decoded}
+	}))
+	t.Cleanup(server.Close)
+
+	exp, err := newSpanExporter(exporterConfig{
+		Name:        "json",
+		Protocol:    protocolOTLPHTTP,
+		Endpoint:    server.URL + "/v1/traces",
+		Encoding:    encodingJSON,
+		Compression: compressionGzip,
+		Headers:     map[string]string{"X-Tenant": "shop"},
+	})
+	require.NoError(t, err)
+
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exp))
+	ctx, parent := tp.Tracer("test").Start(context.Background(), "parent")
+	_, child := tp.Tracer("test").Start(ctx, "child")
+	child.End()
+
+	got := <-requests
+
+	assert.Equal(t, "application/json", got.header.Get("Content-Type"))
+	assert.Equal(t, "shop", got.header.Get("X-Tenant"))
+
+	span := got.body["resourceSpans"].([]any)[0].(map[string]any)["scopeSpans"].([]any)[0].(map[string]any)["spans"].([]any)[0].(map[string]any) //nolint:forcetypeassert // the structure is given by OTLP/JSON
+	assert.Equal(t, "child", span["name"])
+	assert.Equal(t, child.SpanContext().TraceID().String(), span["traceId"], "IDs are hex encoded")
+	assert.Equal(t, parent.SpanContext().SpanID().String(), span["parentSpanId"])
+	assert.InDelta(t, 1, span["kind"], 0, "enums are encoded as numbers")
+
+	require.NoError(t, tp.Shutdown(context.Background()))
+}
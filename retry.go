@@ -0,0 +1,150 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracegrpc"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace/otlptracehttp"
+)
+
+const (
+	// the defaults of the OTLP exporters
+	defaultExportTimeout   = 10 * time.Second
+	defaultInitialInterval = 5 * time.Second
+	defaultMaxInterval     = 30 * time.Second
+	defaultMaxElapsedTime  = time.Minute
+)
+
+type (
+	// retryConfig configures the exponential backoff of the OTLP exporters, unset values keep the exporter defaults
+	retryConfig struct {
+		Enabled         *bool  `json:"enabled"`
+		InitialInterval string `json:"initialInterval"`
+		MaxInterval     string `json:"maxInterval"`
+		MaxElapsedTime  string `json:"maxElapsedTime"`
+	}
+
+	// exportPolicy holds the parsed timeout and retry settings of an OTLP exporter
+	exportPolicy struct {
+		// timeout is zero if not configured, the exporters then read OTEL_EXPORTER_OTLP_TIMEOUT
+		timeout         time.Duration
+		retry           bool
+		initialInterval time.Duration
+		maxInterval     time.Duration
+		maxElapsedTime  time.Duration
+	}
+
+	// retryableError marks failed exports which may succeed when sent again
+	retryableError struct {
+		err error
+	}
+)
+
+// exportPolicy parses the timeout and retry settings
+func (cfg exporterConfig) exportPolicy() (exportPolicy, error) {
+	var errs []error
+
+	parse := func(key, value string, fallback time.Duration) time.Duration {
+		if value == "" {
+			return fallback
+		}
+
+		d, err := time.ParseDuration(value)
+		if err != nil || d <= 0 {
+			errs = append(errs, fmt.Errorf("%w %s.%s: %q must be a positive duration", errInvalidExporter, cfg.configKey, key, value))
+		}
+
+		return d
+	}
+
+	policy := exportPolicy{
+		timeout:         parse("timeout", cfg.Timeout, 0),
+		retry:           cfg.Retry.Enabled == nil || *cfg.Retry.Enabled,
+		initialInterval: parse("retry.initialInterval", cfg.Retry.InitialInterval, defaultInitialInterval),
+		maxInterval:     parse("retry.maxInterval", cfg.Retry.MaxInterval, defaultMaxInterval),
+		maxElapsedTime:  parse("retry.maxElapsedTime", cfg.Retry.MaxElapsedTime, defaultMaxElapsedTime),
+	}
+
+	return policy, errors.Join(errs...)
+}
+
+func (p exportPolicy) httpOptions() []otlptracehttp.Option {
+	opts := []otlptracehttp.Option{otlptracehttp.WithRetry(otlptracehttp.RetryConfig{
+		Enabled:         p.retry,
+		InitialInterval: p.initialInterval,
+		MaxInterval:     p.maxInterval,
+		MaxElapsedTime:  p.maxElapsedTime,
+	})}
+
+	if p.timeout > 0 {
+		opts = append(opts, otlptracehttp.WithTimeout(p.timeout))
+	}
+
+	return opts
+}
+
+func (p exportPolicy) grpcOptions() []otlptracegrpc.Option {
+	opts := []otlptracegrpc.Option{otlptracegrpc.WithRetry(otlptracegrpc.RetryConfig{
+		Enabled:         p.retry,
+		InitialInterval: p.initialInterval,
+		MaxInterval:     p.maxInterval,
+		MaxElapsedTime:  p.maxElapsedTime,
+	})}
+
+	if p.timeout > 0 {
+		opts = append(opts, otlptracegrpc.WithTimeout(p.timeout))
+	}
+
+	return opts
+}
+
+// do calls send until it succeeds, fails with a permanent error or the elapsed time exceeds maxElapsedTime;
+// the whole call including all retries is bound to the timeout
+func (p exportPolicy) do(ctx context.Context, send func(ctx context.Context) error) error {
+	timeout := p.timeout
+	if timeout <= 0 {
+		timeout = defaultExportTimeout
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+
+	start := time.Now()
+	interval := p.initialInterval
+
+	for {
+		err := send(ctx)
+
+		var retryable *retryableError
+		if err == nil || !p.retry || !errors.As(err, &retryable) {
+			return err
+		}
+
+		if time.Since(start)+interval > p.maxElapsedTime {
+			return fmt.Errorf("giving up after %s: %w", time.Since(start).Round(time.Millisecond), err)
+		}
+
+		timer := time.NewTimer(interval)
+
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+
+			return fmt.Errorf("%w: %w", ctx.Err(), err)
+		case <-timer.C:
+		}
+
+		interval = min(2*interval, p.maxInterval) //nolint:mnd // exponential backoff
+	}
+}
+
+func (e *retryableError) Error() string {
+	return e.err.Error()
+}
+
+func (e *retryableError) Unwrap() error {
+	return e.err
+}
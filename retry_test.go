@@ -0,0 +1,163 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private retry policy
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+var errPermanent = errors.New("permanent")
+
+func TestExporterConfig_ExportPolicy(t *testing.T) {
+	t.Parallel()
+
+	disabled := false
+
+	policy, err := exporterConfig{}.exportPolicy()
+	require.NoError(t, err)
+	assert.Equal(t, exportPolicy{
+		retry:           true,
+		initialInterval: defaultInitialInterval,
+		maxInterval:     defaultMaxInterval,
+		maxElapsedTime:  defaultMaxElapsedTime,
+	}, policy)
+
+	policy, err = exporterConfig{
+		Timeout: "2s",
+		Retry:   retryConfig{Enabled: &disabled, InitialInterval: "100ms", MaxInterval: "1s", MaxElapsedTime: "5s"},
+	}.exportPolicy()
+	require.NoError(t, err)
+	assert.Equal(t, exportPolicy{
+		timeout:         2 * time.Second,
+		initialInterval: 100 * time.Millisecond,
+		maxInterval:     time.Second,
+		maxElapsedTime:  5 * time.Second,
+	}, policy)
+
+	_, err = exporterConfig{configKey: "test", Timeout: "-1s", Retry: retryConfig{MaxInterval: "long"}}.exportPolicy()
+	require.ErrorIs(t, err, errInvalidExporter)
+	assert.ErrorContains(t, err, `test.timeout: "-1s" must be a positive duration`)
+	assert.ErrorContains(t, err, `test.retry.maxInterval: "long" must be a positive duration`)
+}
+
+func TestExportPolicy_Do(t *testing.T) {
+	t.Parallel()
+
+	policy := exportPolicy{retry: true, initialInterval: time.Millisecond, maxInterval: 2 * time.Millisecond, maxElapsedTime: time.Second}
+
+	t.Run("retry retryable errors", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+
+		err := policy.do(context.Background(), func(context.Context) error {
+			calls++
+			if calls < 3 {
+				return &retryableError{err: errExport}
+			}
+
+			return nil
+		})
+
+		require.NoError(t, err)
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("permanent errors are not retried", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+
+		err := policy.do(context.Background(), func(context.Context) error {
+			calls++
+
+			return errPermanent
+		})
+
+		require.ErrorIs(t, err, errPermanent)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("disabled retry", func(t *testing.T) {
+		t.Parallel()
+
+		var calls int
+
+		err := exportPolicy{}.do(context.Background(), func(context.Context) error {
+			calls++
+
+			return &retryableError{err: errExport}
+		})
+
+		require.ErrorIs(t, err, errExport)
+		assert.Equal(t, 1, calls)
+	})
+
+	t.Run("give up after the max elapsed time", func(t *testing.T) {
+		t.Parallel()
+
+		short := exportPolicy{retry: true, initialInterval: 5 * time.Millisecond, maxInterval: 5 * time.Millisecond, maxElapsedTime: 20 * time.Millisecond}
+
+		err := short.do(context.Background(), func(context.Context) error {
+			return &retryableError{err: errExport}
+		})
+
+		require.ErrorIs(t, err, errExport)
+		assert.ErrorContains(t, err, "giving up after")
+	})
+
+	t.Run("the timeout bounds all attempts", func(t *testing.T) {
+		t.Parallel()
+
+		slow := exportPolicy{timeout: 20 * time.Millisecond, retry: true, initialInterval: time.Hour, maxInterval: time.Hour, maxElapsedTime: 2 * time.Hour}
+
+		start := time.Now()
+		err := slow.do(context.Background(), func(context.Context) error {
+			return &retryableError{err: errExport}
+		})
+
+		require.ErrorIs(t, err, context.DeadlineExceeded)
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+func TestOTLPJSONClient_Retry(t *testing.T) {
+	t.Parallel()
+
+	var calls atomic.Int32
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		if calls.Add(1) < 3 {
+			w.WriteHeader(http.StatusServiceUnavailable)
+
+			return
+		}
+	}))
+	t.Cleanup(server.Close)
+
+	exp, err := newSpanExporter(exporterConfig{
+		Name:     "json",
+		Protocol: protocolOTLPHTTP,
+		Endpoint: server.URL + "/v1/traces",
+		Encoding: encodingJSON,
+		Retry:    retryConfig{InitialInterval: "1ms", MaxInterval: "1ms"},
+	})
+	require.NoError(t, err)
+	require.IsType(t, new(otlptrace.Exporter), exp)
+
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exp))
+	_, span := tp.Tracer("test").Start(context.Background(), "span")
+	span.End()
+
+	assert.Equal(t, int32(3), calls.Load())
+	require.NoError(t, tp.Shutdown(context.Background()))
+}
@@ -0,0 +1,52 @@
+package opentelemetry
+
+import (
+	"context"
+	"math"
+	"net/http"
+
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/web"
+)
+
+const defaultFallbackSpanName = "incoming request"
+
+type (
+	// routeSpanNamer renames the server span after the router matched the request, the span keeps the fallback name
+	// if no route matched
+	routeSpanNamer struct{}
+)
+
+var _ web.PrioritizedFilter = (*routeSpanNamer)(nil)
+
+func (*routeSpanNamer) Filter(ctx context.Context, req *web.Request, w http.ResponseWriter, chain *web.FilterChain) web.Result {
+	if handler := req.Handler(); handler != nil {
+		nameServerSpan(ctx, handler.GetHandlerName(), handler.GetPath())
+	}
+
+	return chain.Next(ctx, req, w)
+}
+
+// Priority runs the filter first, so that spans of other filters already belong to the renamed span
+func (*routeSpanNamer) Priority() int {
+	return math.MaxInt
+}
+
+// nameServerSpan uses the route handle and template for the span name, e.g. "product.view /p/:sku"
+func nameServerSpan(ctx context.Context, handlerName, route string) {
+	if handlerName == "" || route == "" {
+		return
+	}
+
+	span := trace.SpanFromContext(ctx)
+	span.SetName(handlerName + " " + route)
+	span.SetAttributes(semconv.HTTPRoute(route))
+
+	// the route is added to the request metrics as well
+	if labeler, ok := otelhttp.LabelerFromContext(ctx); ok {
+		labeler.Add(semconv.HTTPRoute(route))
+	}
+}
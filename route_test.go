@@ -0,0 +1,56 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private span naming
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/contrib/instrumentation/net/http/otelhttp"
+	"go.opentelemetry.io/otel/attribute"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+)
+
+func TestNameServerSpan(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		name        string
+		handlerName string
+		route       string
+		wantName    string
+		wantRoute   bool
+	}{
+		{name: "matched route", handlerName: "product.view", route: "/p/:sku", wantName: "product.view /p/:sku", wantRoute: true},
+		{name: "no route", wantName: "incoming request"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Parallel()
+
+			exp := tracetest.NewInMemoryExporter()
+			tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exp))
+
+			handler := otelhttp.NewHandler(
+				http.HandlerFunc(func(_ http.ResponseWriter, r *http.Request) {
+					nameServerSpan(r.Context(), tt.handlerName, tt.route)
+				}),
+				"incoming request",
+				otelhttp.WithTracerProvider(tp),
+				otelhttp.WithSpanNameFormatter(func(string, *http.Request) string { return defaultFallbackSpanName }),
+			)
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/p/4711", nil))
+
+			spans := exp.GetSpans()
+			require.Len(t, spans, 1)
+			assert.Equal(t, tt.wantName, spans[0].Name)
+
+			attrs := attribute.NewSet(spans[0].Attributes...)
+			assert.Equal(t, tt.wantRoute, attrs.HasValue(semconv.HTTPRouteKey))
+		})
+	}
+}
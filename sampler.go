@@ -1,6 +1,7 @@
 package opentelemetry
 
 import (
+	"errors"
 	"fmt"
 	"strings"
 
@@ -14,11 +15,17 @@ import (
 type configuredURLPrefixSampler struct {
 	allowlist []string
 	blocklist []string
+	// root decides on incoming requests which pass the allowlist and blocklist, all are sampled if nil
+	root    tracesdk.Sampler
+	metrics *pipelineMetrics
+	// problems of the list config, reported by the module as part of its configuration error
+	problems []error
 }
 
 // alwaysSampleSpanKindClient enforces sampling of outgoing http requests (client)
 type alwaysSampleSpanKindClient struct {
-	base tracesdk.Sampler
+	base    tracesdk.Sampler
+	metrics *pipelineMetrics
 }
 
 var _ tracesdk.Sampler = (*configuredURLPrefixSampler)(nil)
@@ -36,12 +43,12 @@ func (c *configuredURLPrefixSampler) Inject(
 
 		err := cfg.Allowlist.MapInto(&allowed)
 		if err != nil {
-			panic(fmt.Errorf("failed to map flamingo.opentelemetry.tracing.sampler.allowlist: %w", err))
+			c.problems = append(c.problems, fmt.Errorf("failed to map flamingo.opentelemetry.tracing.sampler.allowlist: %w", err))
 		}
 
 		err = cfg.Blocklist.MapInto(&blocked)
 		if err != nil {
-			panic(fmt.Errorf("failed to map flamingo.opentelemetry.tracing.sampler.blocklist: %w", err))
+			c.problems = append(c.problems, fmt.Errorf("failed to map flamingo.opentelemetry.tracing.sampler.blocklist: %w", err))
 		}
 
 		c.allowlist = allowed
@@ -51,56 +58,87 @@ func (c *configuredURLPrefixSampler) Inject(
 	return c
 }
 
+// validate reports all list entries which can never match a request target
+func (c *configuredURLPrefixSampler) validate() error {
+	var errs []error
+
+	for _, p := range c.allowlist {
+		if !strings.HasPrefix(p, "/") {
+			errs = append(errs, &categorizedError{
+				category: errorCategorySampler,
+				err:      fmt.Errorf("%w %q in flamingo.opentelemetry.tracing.sampler.allowlist: must start with /", errInvalidSamplerPattern, p),
+			})
+		}
+	}
+
+	for _, p := range c.blocklist {
+		if !strings.HasPrefix(p, "/") {
+			errs = append(errs, &categorizedError{
+				category: errorCategorySampler,
+				err:      fmt.Errorf("%w %q in flamingo.opentelemetry.tracing.sampler.blocklist: must start with /", errInvalidSamplerPattern, p),
+			})
+		}
+	}
+
+	return errors.Join(errs...)
+}
+
 func (c *configuredURLPrefixSampler) ShouldSample(params tracesdk.SamplingParameters) tracesdk.SamplingResult {
 	psc := trace.SpanContextFromContext(params.ParentContext)
-	target := extractTarget(params)
+	decision, rule := c.decide(psc, extractTarget(params))
+
+	if c.root != nil && decision == tracesdk.RecordAndSample && rule != samplerRuleParent {
+		result := c.root.ShouldSample(params)
+		c.metrics.recordSamplingDecision(params.ParentContext, result.Decision, samplerRuleRoot)
+
+		return result
+	}
+
+	c.metrics.recordSamplingDecision(params.ParentContext, decision, rule)
 
+	return tracesdk.SamplingResult{
+		Decision:   decision,
+		Tracestate: psc.TraceState(),
+	}
+}
+
+// decide returns the sampling decision for the target together with the rule which led to it
+func (c *configuredURLPrefixSampler) decide(psc trace.SpanContext, target string) (tracesdk.SamplingDecision, string) {
 	// if this is not an incoming request, we decide by parent span
 	if target == "" {
-		decision := tracesdk.Drop
-
 		if psc.IsSampled() {
-			decision = tracesdk.RecordAndSample
+			return tracesdk.RecordAndSample, samplerRuleParent
 		}
 
-		return tracesdk.SamplingResult{
-			Decision:   decision,
-			Tracestate: psc.TraceState(),
-		}
+		return tracesdk.Drop, samplerRuleParent
 	}
 
 	// empty allowed means all
 	sample := len(c.allowlist) == 0
+	rule := samplerRuleDefault
 	// decide if we should sample based on the allowlist
 	for _, p := range c.allowlist {
 		if strings.HasPrefix(target, p) {
 			sample = true
+			rule = samplerRuleAllowlist
+
 			break
 		}
 	}
 
 	// we do not sample unless the parent is sampled
 	if !sample {
-		return tracesdk.SamplingResult{
-			Decision:   tracesdk.Drop,
-			Tracestate: psc.TraceState(),
-		}
+		return tracesdk.Drop, samplerRuleAllowlist
 	}
 
 	// check sampling decision against blocked
 	for _, p := range c.blocklist {
 		if strings.HasPrefix(target, p) {
-			return tracesdk.SamplingResult{
-				Decision:   tracesdk.Drop,
-				Tracestate: psc.TraceState(),
-			}
+			return tracesdk.Drop, samplerRuleBlocklist
 		}
 	}
 
-	return tracesdk.SamplingResult{
-		Decision:   tracesdk.RecordAndSample,
-		Tracestate: psc.TraceState(),
-	}
+	return tracesdk.RecordAndSample, rule
 }
 
 func extractTarget(params tracesdk.SamplingParameters) string {
@@ -124,12 +162,19 @@ func (c *configuredURLPrefixSampler) Description() string {
 	allowlist := strings.Join(c.allowlist, ",")
 	blocklist := strings.Join(c.blocklist, ",")
 
+	if c.root != nil {
+		return fmt.Sprintf("ConfiguredURLPrefixSampler{allowlist:%s,blocklist:%s,root:%s}", allowlist, blocklist, c.root.Description())
+	}
+
 	return fmt.Sprintf("ConfiguredURLPrefixSampler{allowlist:%s,blocklist:%s}", allowlist, blocklist)
 }
 
 func (s *alwaysSampleSpanKindClient) ShouldSample(parameters tracesdk.SamplingParameters) tracesdk.SamplingResult {
 	if parameters.Kind == trace.SpanKindClient {
-		return tracesdk.AlwaysSample().ShouldSample(parameters)
+		result := tracesdk.AlwaysSample().ShouldSample(parameters)
+		s.metrics.recordSamplingDecision(parameters.ParentContext, result.Decision, samplerRuleClient)
+
+		return result
 	}
 
 	return s.base.ShouldSample(parameters)
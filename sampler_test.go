@@ -6,8 +6,10 @@ import (
 	"testing"
 
 	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
 	"go.opentelemetry.io/otel/attribute"
 	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
 	"go.opentelemetry.io/otel/trace"
 
 	"flamingo.me/flamingo/v3/framework/config"
@@ -16,40 +18,36 @@ import (
 func TestConfiguredURLPrefixSampler_Inject(t *testing.T) {
 	t.Parallel()
 
-	t.Run("should panic on invalid allowlist", func(t *testing.T) {
+	t.Run("should record invalid allowlist", func(t *testing.T) {
 		t.Parallel()
 
-		sampler := new(configuredURLPrefixSampler)
+		sampler := new(configuredURLPrefixSampler).Inject(
+			&struct {
+				Allowlist config.Slice `inject:"config:flamingo.opentelemetry.tracing.sampler.allowlist,optional"`
+				Blocklist config.Slice `inject:"config:flamingo.opentelemetry.tracing.sampler.blocklist,optional"`
+			}{
+				Allowlist: []any{"1", 2, false},
+			},
+		)
 
-		assert.Panics(t,
-			func() {
-				sampler.Inject(
-					&struct {
-						Allowlist config.Slice `inject:"config:flamingo.opentelemetry.tracing.sampler.allowlist,optional"`
-						Blocklist config.Slice `inject:"config:flamingo.opentelemetry.tracing.sampler.blocklist,optional"`
-					}{
-						Allowlist: []any{"1", 2, false},
-					},
-				)
-			})
+		require.Len(t, sampler.problems, 1)
+		assert.ErrorContains(t, sampler.problems[0], "flamingo.opentelemetry.tracing.sampler.allowlist")
 	})
 
-	t.Run("should panic on invalid blocklist", func(t *testing.T) {
+	t.Run("should record invalid blocklist", func(t *testing.T) {
 		t.Parallel()
 
-		sampler := new(configuredURLPrefixSampler)
+		sampler := new(configuredURLPrefixSampler).Inject(
+			&struct {
+				Allowlist config.Slice `inject:"config:flamingo.opentelemetry.tracing.sampler.allowlist,optional"`
+				Blocklist config.Slice `inject:"config:flamingo.opentelemetry.tracing.sampler.blocklist,optional"`
+			}{
+				Blocklist: []any{"1", 2, false},
+			},
+		)
 
-		assert.Panics(t,
-			func() {
-				sampler.Inject(
-					&struct {
-						Allowlist config.Slice `inject:"config:flamingo.opentelemetry.tracing.sampler.allowlist,optional"`
-						Blocklist config.Slice `inject:"config:flamingo.opentelemetry.tracing.sampler.blocklist,optional"`
-					}{
-						Blocklist: []any{"1", 2, false},
-					},
-				)
-			})
+		require.Len(t, sampler.problems, 1)
+		assert.ErrorContains(t, sampler.problems[0], "flamingo.opentelemetry.tracing.sampler.blocklist")
 	})
 }
 
@@ -288,3 +286,31 @@ func TestSpanKindBasedSampler_Description(t *testing.T) {
 
 	assert.Equal(t, expectedDescription, s.Description())
 }
+
+func TestConfiguredURLPrefixSampler_Root(t *testing.T) {
+	t.Parallel()
+
+	sampler := &configuredURLPrefixSampler{
+		blocklist: []string{"/static"},
+		root:      tracesdk.NeverSample(),
+	}
+
+	for _, path := range []string{"/", "/static/app.css"} {
+		result := sampler.ShouldSample(tracesdk.SamplingParameters{
+			ParentContext: context.Background(),
+			Attributes:    []attribute.KeyValue{semconv.URLPath(path)},
+		})
+
+		assert.Equal(t, tracesdk.Drop, result.Decision, path)
+	}
+
+	sampler.root = tracesdk.AlwaysSample()
+
+	result := sampler.ShouldSample(tracesdk.SamplingParameters{
+		ParentContext: context.Background(),
+		Attributes:    []attribute.KeyValue{semconv.URLPath("/")},
+	})
+
+	assert.Equal(t, tracesdk.RecordAndSample, result.Decision)
+	assert.Equal(t, "ConfiguredURLPrefixSampler{allowlist:,blocklist:/static,root:AlwaysOnSampler}", sampler.Description())
+}
@@ -2,18 +2,28 @@ package opentelemetry
 
 import (
 	"context"
+	"errors"
+	"time"
 
 	"flamingo.me/flamingo/v3/framework/flamingo"
 	"go.opentelemetry.io/otel"
 )
 
+const defaultShutdownTimeout = 5 * time.Second
+
+var errInvalidShutdownTimeout = errors.New("timeout must be positive")
+
 type (
 	Shutdowner interface {
 		Shutdown(ctx context.Context) error
 	}
 
+	// Listener flushes and closes the components started by the module, the global providers without a module
 	Listener struct {
-		logger flamingo.Logger
+		logger     flamingo.Logger
+		timeout    time.Duration
+		metrics    *pipelineMetrics
+		components []component
 	}
 )
 
@@ -27,26 +37,50 @@ func (l *Listener) Inject(
 }
 
 func (l *Listener) Notify(ctx context.Context, event flamingo.Event) {
-	if _, ok := event.(*flamingo.ShutdownEvent); ok {
-		tp := otel.GetTracerProvider()
-		if s, ok := tp.(Shutdowner); ok {
-			l.shutdown(ctx, s)
-		}
+	if _, ok := event.(*flamingo.ShutdownEvent); !ok {
+		return
+	}
 
-		mp := otel.GetMeterProvider()
-		if s, ok := mp.(Shutdowner); ok {
-			l.shutdown(ctx, s)
-		}
+	// the context of the event may already be cancelled, the remaining telemetry is sent anyway
+	ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shutdownTimeout(l.timeout))
+	defer cancel()
+
+	before := l.metrics.summary()
+
+	if err := l.shutdown(ctx); err != nil {
+		l.log().Errorf("shutdown failed: %v", err)
+	}
+
+	if l.metrics != nil {
+		after := l.metrics.summary()
+
+		// spans dropped before the shutdown, e.g. by a full queue, are lost as well and part of the summary
+		l.log().Infof("flushed telemetry on shutdown: %d spans exported, %d failed, %d left in the queues, %d dropped since the start",
+			after.exported-before.exported, after.failed-before.failed, after.queued, after.dropped)
 	}
 }
 
-func (l *Listener) shutdown(ctx context.Context, s Shutdowner) {
-	l.log().Debugf("Shutdown OpenTelemetry: %T", s)
+// shutdown flushes all components first, so that e.g. metrics recorded while the spans are exported are flushed as
+// well, and closes them afterwards
+func (l *Listener) shutdown(ctx context.Context) error {
+	components := l.components
+	if components == nil {
+		components = []component{
+			providerComponent("meter provider", otel.GetMeterProvider()),
+			providerComponent("tracer provider", otel.GetTracerProvider()),
+		}
+	}
 
-	err := s.Shutdown(ctx)
-	if err != nil {
-		l.log().Error("", err)
+	return errors.Join(flushComponents(ctx, components), closeComponents(ctx, components))
+}
+
+// shutdownTimeout returns the configured timeout, or the default one if it is not set
+func shutdownTimeout(timeout time.Duration) time.Duration {
+	if timeout <= 0 {
+		return defaultShutdownTimeout
 	}
+
+	return timeout
 }
 
 func (l *Listener) log() flamingo.Logger {
@@ -3,11 +3,16 @@ package opentelemetry_test
 import (
 	"context"
 	"errors"
+	"sync/atomic"
 	"testing"
+	"time"
 
 	"flamingo.me/flamingo/v3/framework/flamingo"
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/mock"
 	"go.opentelemetry.io/otel"
 	noopMetric "go.opentelemetry.io/otel/metric/noop"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
 	noopTrace "go.opentelemetry.io/otel/trace/noop"
 
 	"flamingo.me/opentelemetry"
@@ -24,8 +29,21 @@ type (
 		noopMetric.MeterProvider
 		mocks.Shutdowner
 	}
+
+	// countingExporter keeps its count on shutdown, unlike the in-memory exporter
+	countingExporter struct {
+		exported atomic.Int64
+	}
 )
 
+func (e *countingExporter) ExportSpans(_ context.Context, spans []tracesdk.ReadOnlySpan) error {
+	e.exported.Add(int64(len(spans)))
+
+	return nil
+}
+
+func (*countingExporter) Shutdown(context.Context) error { return nil }
+
 var errShutdown = errors.New("shutdown error")
 
 func TestListener_Notify(t *testing.T) { //nolint:tparallel // no parallel subtests possible because of global state manipulation
@@ -62,11 +80,11 @@ func TestListener_Notify(t *testing.T) { //nolint:tparallel // no parallel subte
 	for _, tt := range tests { //nolint:paralleltest // no parallel test possible because of global state manipulation
 		t.Run(tt.name, func(t *testing.T) {
 			tp := new(tracerProvider)
-			tp.Shutdowner.EXPECT().Shutdown(context.Background()).Once().Return(tt.traceShutdownError)
+			tp.Shutdowner.EXPECT().Shutdown(mock.Anything).Once().Return(tt.traceShutdownError)
 			otel.SetTracerProvider(tp)
 
 			mp := new(meterProvider)
-			mp.Shutdowner.EXPECT().Shutdown(context.Background()).Once().Return(tt.meterShutdownError)
+			mp.Shutdowner.EXPECT().Shutdown(mock.Anything).Once().Return(tt.meterShutdownError)
 			otel.SetMeterProvider(mp)
 
 			l := new(opentelemetry.Listener).Inject(new(flamingo.NullLogger))
@@ -75,3 +93,20 @@ func TestListener_Notify(t *testing.T) { //nolint:tparallel // no parallel subte
 		})
 	}
 }
+
+//nolint:paralleltest // replaces the global tracer provider
+func TestListener_Notify_FlushesWithCancelledContext(t *testing.T) {
+	exp := new(countingExporter)
+	tp := tracesdk.NewTracerProvider(tracesdk.WithBatcher(exp, tracesdk.WithBatchTimeout(time.Hour)))
+	otel.SetTracerProvider(tp)
+
+	_, span := tp.Tracer("test").Start(context.Background(), "last request")
+	span.End()
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	new(opentelemetry.Listener).Inject(new(flamingo.NullLogger)).Notify(ctx, new(flamingo.ShutdownEvent))
+
+	assert.Equal(t, int64(1), exp.exported.Load(), "the queued span is exported before the shutdown")
+}
@@ -0,0 +1,570 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/exporters/otlp/otlptrace"
+	"go.opentelemetry.io/otel/sdk/instrumentation"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	"go.opentelemetry.io/otel/trace"
+	coltracepb "go.opentelemetry.io/proto/otlp/collector/trace/v1"
+	commonpb "go.opentelemetry.io/proto/otlp/common/v1"
+	tracepb "go.opentelemetry.io/proto/otlp/trace/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+const (
+	spoolFileSuffix = ".otlp"
+	// spoolReplayLimit is the number of spooled batches replayed after one successful export
+	spoolReplayLimit = 10
+
+	dropReasonSpoolSize    = "spool_size"
+	dropReasonSpoolAge     = "spool_age"
+	dropReasonSpoolWrite   = "spool_write"
+	dropReasonSpoolCorrupt = "spool_corrupt"
+
+	// flags of the OTLP span which tell if the parent is remote
+	spanFlagsHasIsRemote = 0x100
+	spanFlagsIsRemote    = 0x200
+)
+
+var errInvalidSpoolFile = errors.New("invalid spool file")
+
+type (
+	// spoolConfig enables the spooling of failed batches, the exporter name is used as sub directory
+	spoolConfig struct {
+		Directory string `json:"directory"`
+		// MaxSize in MiB of all spooled batches of the exporter, 0 disables the limit
+		MaxSize int `json:"maxSize"`
+		// MaxAge after which spooled batches are dropped, empty disables the limit
+		MaxAge string `json:"maxAge"`
+	}
+
+	// spoolingExporter writes batches which failed to export to disk as OTLP protobuf files,
+	// they are replayed oldest first in the background once an export succeeds again
+	spoolingExporter struct {
+		name    string
+		next    tracesdk.SpanExporter
+		dir     string
+		maxSize int64
+		maxAge  time.Duration
+		timeout time.Duration
+		metrics *pipelineMetrics
+		errors  otel.ErrorHandler
+
+		mu       sync.Mutex
+		sequence uint64
+
+		// replays requests a replay, stopReplay ends the replay goroutine which closes replayDone
+		replays    chan struct{}
+		stopReplay context.CancelFunc
+		replayDone chan struct{}
+	}
+
+	spoolFile struct {
+		path    string
+		created time.Time
+		spans   int64
+		size    int64
+	}
+
+	// captureClient keeps the OTLP representation of the spans instead of uploading it
+	captureClient struct {
+		resourceSpans []*tracepb.ResourceSpans
+	}
+)
+
+var (
+	_ tracesdk.SpanExporter = (*spoolingExporter)(nil)
+	_ otlptrace.Client      = (*captureClient)(nil)
+)
+
+// newSpoolingExporter creates the spool of an exporter, failed spool writes and corrupt spool files are reported to
+// the error handler
+func newSpoolingExporter(
+	cfg exporterConfig,
+	next tracesdk.SpanExporter,
+	metrics *pipelineMetrics,
+	errorHandler otel.ErrorHandler,
+) (*spoolingExporter, error) {
+	var maxAge time.Duration
+
+	if cfg.Spool.MaxAge != "" {
+		var err error
+
+		maxAge, err = time.ParseDuration(cfg.Spool.MaxAge)
+		if err != nil {
+			return nil, fmt.Errorf("%w %s.spool.maxAge: %w", errInvalidExporter, cfg.configKey, err)
+		}
+	}
+
+	// replayed batches are exported within the timeout of a regular export, a hanging backend does not stop the replay
+	policy, err := cfg.exportPolicy()
+	if err != nil {
+		return nil, err
+	}
+
+	timeout := policy.timeout
+	if timeout <= 0 {
+		timeout = defaultExportTimeout
+	}
+
+	dir := filepath.Join(cfg.Spool.Directory, cfg.Name)
+	if err := os.MkdirAll(dir, 0o750); err != nil { //nolint:mnd // only readable by the group
+		return nil, fmt.Errorf("%w %s.spool.directory: %w", errInvalidExporter, cfg.configKey, err)
+	}
+
+	ctx, cancel := context.WithCancel(context.Background())
+
+	e := &spoolingExporter{
+		name:       cfg.Name,
+		next:       next,
+		dir:        dir,
+		maxSize:    int64(cfg.Spool.MaxSize) * mebibyte,
+		maxAge:     maxAge,
+		timeout:    timeout,
+		metrics:    metrics,
+		errors:     errorHandler,
+		replays:    make(chan struct{}, 1),
+		stopReplay: cancel,
+		replayDone: make(chan struct{}),
+	}
+
+	go e.replayLoop(ctx)
+
+	return e, nil
+}
+
+// ExportSpans spools the batch if the export fails, a successful export starts the replay of the spooled batches
+// without delaying the export
+func (e *spoolingExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if err := e.next.ExportSpans(ctx, spans); err != nil {
+		e.spool(spans)
+
+		return err //nolint:wrapcheck // the error of the wrapped exporter is reported unchanged
+	}
+
+	select {
+	case e.replays <- struct{}{}:
+	default: // a replay is already pending
+	}
+
+	return nil
+}
+
+func (e *spoolingExporter) Shutdown(ctx context.Context) error {
+	return errors.Join(e.stop(ctx), e.next.Shutdown(ctx))
+}
+
+// stop ends the replay goroutine, a running replay is cancelled and the remaining batches stay spooled
+func (e *spoolingExporter) stop(ctx context.Context) error {
+	e.stopReplay()
+
+	select {
+	case <-e.replayDone:
+		return nil
+	case <-ctx.Done():
+		return fmt.Errorf("failed to stop the spool replay of %s: %w", e.name, ctx.Err())
+	}
+}
+
+func (e *spoolingExporter) replayLoop(ctx context.Context) {
+	defer close(e.replayDone)
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-e.replays:
+			e.replay(ctx)
+		}
+	}
+}
+
+// spool writes the batch to a new file, errors are reported to the error handler and the spans are dropped
+func (e *spoolingExporter) spool(spans []tracesdk.ReadOnlySpan) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	count := int64(len(spans))
+
+	data, err := encodeSpans(spans)
+	if err == nil {
+		e.sequence++
+		name := fmt.Sprintf("%020d-%06d-%d%s", time.Now().UnixNano(), e.sequence%1_000_000, count, spoolFileSuffix)
+		err = writeFileAtomic(filepath.Join(e.dir, name), data)
+	}
+
+	if err != nil {
+		e.metrics.recordDropped(e.name, dropReasonSpoolWrite, count)
+		e.errors.Handle(&exporterError{exporter: e.name, err: fmt.Errorf("failed to spool spans: %w", err)})
+
+		return
+	}
+
+	e.metrics.recordSpooled(e.name, count)
+	e.enforceLimits()
+}
+
+// replay exports the oldest spooled batches until one of them fails, the lock is only held to pick, remove and
+// restore the files, so that failed exports of the batch processor are spooled while a replayed export is pending
+func (e *spoolingExporter) replay(ctx context.Context) {
+	e.mu.Lock()
+	files := e.enforceLimits()
+	e.mu.Unlock()
+
+	for _, file := range files[:min(len(files), spoolReplayLimit)] {
+		if ctx.Err() != nil {
+			return
+		}
+
+		data, ok := e.take(file)
+		if !ok {
+			continue
+		}
+
+		spans, err := decodeSpoolFile(file.path, data)
+		if err != nil {
+			e.metrics.recordDropped(e.name, dropReasonSpoolCorrupt, file.spans)
+			e.errors.Handle(&exporterError{exporter: e.name, err: err})
+
+			continue
+		}
+
+		exportCtx, cancel := context.WithTimeout(ctx, e.timeout)
+		err = e.next.ExportSpans(exportCtx, spans)
+
+		cancel()
+
+		if err != nil {
+			e.restore(file, data)
+
+			return
+		}
+
+		e.metrics.recordReplayed(e.name, file.spans)
+	}
+}
+
+// take reads and removes a spooled batch, it is false if the batch was removed by the limits in the meantime
+func (e *spoolingExporter) take(file spoolFile) ([]byte, bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	data, err := os.ReadFile(file.path)
+	if err != nil {
+		return nil, false
+	}
+
+	_ = os.Remove(file.path)
+
+	return data, true
+}
+
+// restore writes back a batch whose replay failed, under its old name so that it keeps its age and position
+func (e *spoolingExporter) restore(file spoolFile, data []byte) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	if err := writeFileAtomic(file.path, data); err != nil {
+		e.metrics.recordDropped(e.name, dropReasonSpoolWrite, file.spans)
+		e.errors.Handle(&exporterError{exporter: e.name, err: fmt.Errorf("failed to restore spooled spans: %w", err)})
+	}
+}
+
+// enforceLimits removes the batches exceeding the age and size limits and returns the remaining ones, oldest first
+func (e *spoolingExporter) enforceLimits() []spoolFile {
+	files := e.files()
+
+	for len(files) > 0 && e.maxAge > 0 && time.Since(files[0].created) > e.maxAge {
+		_ = os.Remove(files[0].path)
+		e.metrics.recordDropped(e.name, dropReasonSpoolAge, files[0].spans)
+		files = files[1:]
+	}
+
+	var size int64
+	for _, f := range files {
+		size += f.size
+	}
+
+	for len(files) > 0 && e.maxSize > 0 && size > e.maxSize {
+		_ = os.Remove(files[0].path)
+		e.metrics.recordDropped(e.name, dropReasonSpoolSize, files[0].spans)
+		size -= files[0].size
+		files = files[1:]
+	}
+
+	return files
+}
+
+// files lists the spooled batches, the file name contains the creation time and the number of spans
+func (e *spoolingExporter) files() []spoolFile {
+	entries, err := os.ReadDir(e.dir)
+	if err != nil {
+		return nil
+	}
+
+	files := make([]spoolFile, 0, len(entries))
+
+	for _, entry := range entries {
+		parts := strings.Split(strings.TrimSuffix(entry.Name(), spoolFileSuffix), "-")
+		if !strings.HasSuffix(entry.Name(), spoolFileSuffix) || len(parts) != 3 { //nolint:mnd // time, sequence and count
+			continue
+		}
+
+		nanos, _ := strconv.ParseInt(parts[0], 10, 64)
+		spans, _ := strconv.ParseInt(parts[2], 10, 64)
+
+		info, err := entry.Info()
+		if err != nil {
+			continue
+		}
+
+		files = append(files, spoolFile{
+			path:    filepath.Join(e.dir, entry.Name()),
+			created: time.Unix(0, nanos),
+			spans:   spans,
+			size:    info.Size(),
+		})
+	}
+
+	slices.SortFunc(files, func(a, b spoolFile) int { return strings.Compare(a.path, b.path) })
+
+	return files
+}
+
+func writeFileAtomic(path string, data []byte) error {
+	tmp := path + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0o640); err != nil { //nolint:mnd // only readable by the group
+		return fmt.Errorf("failed to write %s: %w", tmp, err)
+	}
+
+	if err := os.Rename(tmp, path); err != nil {
+		_ = os.Remove(tmp)
+
+		return fmt.Errorf("failed to write %s: %w", path, err)
+	}
+
+	return nil
+}
+
+// encodeSpans converts the spans to an OTLP export request with the transformation of the OTLP exporters
+func encodeSpans(spans []tracesdk.ReadOnlySpan) ([]byte, error) {
+	capture := new(captureClient)
+	if err := otlptrace.NewUnstarted(capture).ExportSpans(context.Background(), spans); err != nil {
+		return nil, fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	data, err := proto.Marshal(&coltracepb.ExportTraceServiceRequest{ResourceSpans: capture.resourceSpans})
+	if err != nil {
+		return nil, fmt.Errorf("failed to encode spans: %w", err)
+	}
+
+	return data, nil
+}
+
+func readSpoolFile(path string) ([]tracesdk.ReadOnlySpan, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("%w %s: %w", errInvalidSpoolFile, path, err)
+	}
+
+	return decodeSpoolFile(path, data)
+}
+
+// decodeSpoolFile restores the spans of a spooled batch read from path
+func decodeSpoolFile(path string, data []byte) ([]tracesdk.ReadOnlySpan, error) {
+	var request coltracepb.ExportTraceServiceRequest
+	if err := proto.Unmarshal(data, &request); err != nil {
+		return nil, fmt.Errorf("%w %s: %w", errInvalidSpoolFile, path, err)
+	}
+
+	return decodeSpans(request.GetResourceSpans()), nil
+}
+
+// decodeSpans restores the read only spans from their OTLP representation
+func decodeSpans(resourceSpans []*tracepb.ResourceSpans) []tracesdk.ReadOnlySpan {
+	var spans []tracesdk.ReadOnlySpan
+
+	for _, rs := range resourceSpans {
+		res := resource.NewWithAttributes(rs.GetSchemaUrl(), decodeAttributes(rs.GetResource().GetAttributes())...)
+
+		for _, ss := range rs.GetScopeSpans() {
+			scope := instrumentation.Scope{
+				Name:       ss.GetScope().GetName(),
+				Version:    ss.GetScope().GetVersion(),
+				SchemaURL:  ss.GetSchemaUrl(),
+				Attributes: attribute.NewSet(decodeAttributes(ss.GetScope().GetAttributes())...),
+			}
+
+			for _, s := range ss.GetSpans() {
+				spans = append(spans, decodeSpan(s, res, scope).Snapshot())
+			}
+		}
+	}
+
+	return spans
+}
+
+func decodeSpan(s *tracepb.Span, res *resource.Resource, scope instrumentation.Scope) tracetest.SpanStub {
+	traceID := toTraceID(s.GetTraceId())
+	traceState, _ := trace.ParseTraceState(s.GetTraceState())
+
+	stub := tracetest.SpanStub{
+		Name: s.GetName(),
+		SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID:    traceID,
+			SpanID:     toSpanID(s.GetSpanId()),
+			TraceFlags: trace.TraceFlags(s.GetFlags() & 0xff), //nolint:gosec,mnd // the lower byte holds the trace flags
+			TraceState: traceState,
+		}),
+		SpanKind:             trace.SpanKind(s.GetKind()),                   // both use the values of the OpenTelemetry specification
+		StartTime:            time.Unix(0, int64(s.GetStartTimeUnixNano())), //nolint:gosec // valid until 2262
+		EndTime:              time.Unix(0, int64(s.GetEndTimeUnixNano())),   //nolint:gosec // valid until 2262
+		Attributes:           decodeAttributes(s.GetAttributes()),
+		DroppedAttributes:    int(s.GetDroppedAttributesCount()),
+		DroppedEvents:        int(s.GetDroppedEventsCount()),
+		DroppedLinks:         int(s.GetDroppedLinksCount()),
+		Resource:             res,
+		InstrumentationScope: scope,
+	}
+
+	if len(s.GetParentSpanId()) == len(trace.SpanID{}) {
+		remote := s.GetFlags()&(spanFlagsHasIsRemote|spanFlagsIsRemote) == spanFlagsHasIsRemote|spanFlagsIsRemote
+		stub.Parent = trace.NewSpanContext(trace.SpanContextConfig{
+			TraceID: traceID,
+			SpanID:  toSpanID(s.GetParentSpanId()),
+			Remote:  remote,
+		})
+	}
+
+	switch s.GetStatus().GetCode() {
+	case tracepb.Status_STATUS_CODE_OK:
+		stub.Status = tracesdk.Status{Code: codes.Ok}
+	case tracepb.Status_STATUS_CODE_ERROR:
+		stub.Status = tracesdk.Status{Code: codes.Error, Description: s.GetStatus().GetMessage()}
+	case tracepb.Status_STATUS_CODE_UNSET:
+	}
+
+	for _, event := range s.GetEvents() {
+		stub.Events = append(stub.Events, tracesdk.Event{
+			Name:                  event.GetName(),
+			Attributes:            decodeAttributes(event.GetAttributes()),
+			DroppedAttributeCount: int(event.GetDroppedAttributesCount()),
+			Time:                  time.Unix(0, int64(event.GetTimeUnixNano())), //nolint:gosec // valid until 2262
+		})
+	}
+
+	for _, link := range s.GetLinks() {
+		linkState, _ := trace.ParseTraceState(link.GetTraceState())
+		stub.Links = append(stub.Links, tracesdk.Link{
+			SpanContext: trace.NewSpanContext(trace.SpanContextConfig{
+				TraceID:    toTraceID(link.GetTraceId()),
+				SpanID:     toSpanID(link.GetSpanId()),
+				TraceFlags: trace.TraceFlags(link.GetFlags() & 0xff), //nolint:gosec,mnd // the lower byte holds the trace flags
+				TraceState: linkState,
+			}),
+			Attributes:            decodeAttributes(link.GetAttributes()),
+			DroppedAttributeCount: int(link.GetDroppedAttributesCount()),
+		})
+	}
+
+	return stub
+}
+
+// decodeAttributes converts the attribute types created by the SDK, other values are kept as string
+func decodeAttributes(kvs []*commonpb.KeyValue) []attribute.KeyValue {
+	attrs := make([]attribute.KeyValue, 0, len(kvs))
+
+	for _, kv := range kvs {
+		attrs = append(attrs, decodeAttribute(kv.GetKey(), kv.GetValue()))
+	}
+
+	return attrs
+}
+
+func decodeAttribute(key string, value *commonpb.AnyValue) attribute.KeyValue {
+	switch v := value.GetValue().(type) {
+	case *commonpb.AnyValue_StringValue:
+		return attribute.String(key, v.StringValue)
+	case *commonpb.AnyValue_BoolValue:
+		return attribute.Bool(key, v.BoolValue)
+	case *commonpb.AnyValue_IntValue:
+		return attribute.Int64(key, v.IntValue)
+	case *commonpb.AnyValue_DoubleValue:
+		return attribute.Float64(key, v.DoubleValue)
+	case *commonpb.AnyValue_ArrayValue:
+		return decodeSliceAttribute(key, v.ArrayValue.GetValues())
+	}
+
+	return attribute.String(key, value.String())
+}
+
+// decodeSliceAttribute converts homogeneous arrays, the element type is taken from the first value
+func decodeSliceAttribute(key string, values []*commonpb.AnyValue) attribute.KeyValue {
+	if len(values) == 0 {
+		return attribute.StringSlice(key, nil)
+	}
+
+	switch values[0].GetValue().(type) {
+	case *commonpb.AnyValue_BoolValue:
+		return attribute.BoolSlice(key, mapValues(values, (*commonpb.AnyValue).GetBoolValue))
+	case *commonpb.AnyValue_IntValue:
+		return attribute.Int64Slice(key, mapValues(values, (*commonpb.AnyValue).GetIntValue))
+	case *commonpb.AnyValue_DoubleValue:
+		return attribute.Float64Slice(key, mapValues(values, (*commonpb.AnyValue).GetDoubleValue))
+	}
+
+	return attribute.StringSlice(key, mapValues(values, (*commonpb.AnyValue).GetStringValue))
+}
+
+// toTraceID and toSpanID copy the IDs, invalid lengths result in invalid IDs instead of a panic
+func toTraceID(b []byte) trace.TraceID {
+	var id trace.TraceID
+
+	copy(id[:], b)
+
+	return id
+}
+
+func toSpanID(b []byte) trace.SpanID {
+	var id trace.SpanID
+
+	copy(id[:], b)
+
+	return id
+}
+
+func mapValues[T any](values []*commonpb.AnyValue, get func(*commonpb.AnyValue) T) []T {
+	result := make([]T, 0, len(values))
+	for _, v := range values {
+		result = append(result, get(v))
+	}
+
+	return result
+}
+
+func (*captureClient) Start(context.Context) error { return nil }
+
+func (*captureClient) Stop(context.Context) error { return nil }
+
+func (c *captureClient) UploadTraces(_ context.Context, protoSpans []*tracepb.ResourceSpans) error {
+	c.resourceSpans = append(c.resourceSpans, protoSpans...)
+
+	return nil
+}
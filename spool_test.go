@@ -0,0 +1,286 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private spooling exporter
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// outageExporter fails while the collector is down
+type outageExporter struct {
+	*tracetest.InMemoryExporter
+	down atomic.Bool
+}
+
+func (e *outageExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if e.down.Load() {
+		return errExport
+	}
+
+	return e.InMemoryExporter.ExportSpans(ctx, spans) //nolint:wrapcheck // test exporter
+}
+
+// hangingExporter fails while the collector is down, otherwise the first export succeeds and later ones hang until
+// their context ends
+type hangingExporter struct {
+	down      atomic.Bool
+	succeeded atomic.Bool
+	hanging   chan struct{}
+}
+
+func (e *hangingExporter) ExportSpans(ctx context.Context, _ []tracesdk.ReadOnlySpan) error {
+	if e.down.Load() {
+		return errExport
+	}
+
+	if e.succeeded.CompareAndSwap(false, true) {
+		return nil
+	}
+
+	select {
+	case e.hanging <- struct{}{}:
+	default:
+	}
+
+	<-ctx.Done()
+
+	return ctx.Err() //nolint:wrapcheck // test exporter
+}
+
+func (*hangingExporter) Shutdown(context.Context) error { return nil }
+
+func recordSpans(t *testing.T, names ...string) []tracesdk.ReadOnlySpan {
+	t.Helper()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSyncer(exp),
+		tracesdk.WithResource(resource.NewSchemaless(semconv.ServiceName("shop"))),
+	)
+
+	for _, name := range names {
+		_, span := tp.Tracer("test").Start(context.Background(), name)
+		span.End()
+	}
+
+	return exp.GetSpans().Snapshots()
+}
+
+func TestSpoolingExporter(t *testing.T) {
+	t.Parallel()
+
+	metrics, reader := newTestPipelineMetrics(t)
+	next := &outageExporter{InMemoryExporter: tracetest.NewInMemoryExporter()}
+	dir := t.TempDir()
+
+	exp, err := newSpoolingExporter(exporterConfig{Name: "otlp", Spool: spoolConfig{Directory: dir, MaxSize: 1}}, next, metrics, ignoreErrors)
+	require.NoError(t, err)
+
+	next.down.Store(true)
+	require.ErrorIs(t, exp.ExportSpans(context.Background(), recordSpans(t, "first", "second")), errExport)
+	require.ErrorIs(t, exp.ExportSpans(context.Background(), recordSpans(t, "third")), errExport)
+
+	files, err := os.ReadDir(filepath.Join(dir, "otlp"))
+	require.NoError(t, err)
+	assert.Len(t, files, 2)
+	assert.Equal(t, int64(3), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.spooled", exporterKey.String("otlp")))
+
+	next.down.Store(false)
+	require.NoError(t, exp.ExportSpans(context.Background(), recordSpans(t, "fourth")))
+
+	// the replay runs in the background
+	assert.Eventually(t, func() bool { return len(next.GetSpans()) == 4 }, time.Second, time.Millisecond)
+
+	var names []string
+	for _, s := range next.GetSpans() {
+		names = append(names, s.Name)
+	}
+
+	assert.Equal(t, []string{"fourth", "first", "second", "third"}, names, "spooled batches are replayed oldest first")
+	assert.Equal(t, int64(3), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.replayed", exporterKey.String("otlp")))
+
+	files, err = os.ReadDir(filepath.Join(dir, "otlp"))
+	require.NoError(t, err)
+	assert.Empty(t, files)
+
+	require.NoError(t, exp.Shutdown(context.Background()))
+	assert.NotPanics(t, func() { <-exp.replayDone }, "the replay goroutine is stopped")
+	require.NoError(t, exp.stop(context.Background()), "stopping again is a no-op")
+}
+
+func TestSpoolingExporter_Limits(t *testing.T) {
+	t.Parallel()
+
+	metrics, reader := newTestPipelineMetrics(t)
+	dir := t.TempDir()
+
+	var handled atomic.Int64
+
+	errorHandler := otel.ErrorHandlerFunc(func(err error) {
+		assert.ErrorContains(t, err, "failed to spool spans")
+		handled.Add(1)
+	})
+
+	exp, err := newSpoolingExporter(exporterConfig{Name: "otlp", Spool: spoolConfig{Directory: dir}}, failingExporter{}, metrics, errorHandler)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, exp.Shutdown(context.Background())) })
+
+	for range 3 {
+		require.Error(t, exp.ExportSpans(context.Background(), recordSpans(t, "span")))
+	}
+
+	files := exp.files()
+	require.Len(t, files, 3)
+
+	// allow only two of the three files
+	exp.maxSize = files[0].size + files[1].size
+	exp.enforceLimits()
+
+	assert.Len(t, exp.files(), 2)
+	assert.Equal(t, int64(1), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.dropped",
+		exporterKey.String("otlp"), reasonKey.String(dropReasonSpoolSize)))
+
+	exp.maxAge = 1
+	exp.enforceLimits()
+
+	assert.Empty(t, exp.files())
+	assert.Equal(t, int64(2), sumOf(t, reader, "flamingo.opentelemetry.exporter.spans.dropped",
+		exporterKey.String("otlp"), reasonKey.String(dropReasonSpoolAge)))
+
+	_, err = newSpoolingExporter(exporterConfig{Name: "otlp", configKey: "test", Spool: spoolConfig{Directory: dir, MaxAge: "a day"}}, failingExporter{}, metrics, errorHandler)
+	assert.ErrorContains(t, err, "test.spool.maxAge")
+
+	exp.dir = filepath.Join(dir, "missing")
+	require.Error(t, exp.ExportSpans(context.Background(), recordSpans(t, "span")))
+	assert.Equal(t, int64(1), handled.Load(), "failed spool writes are passed to the error handler of the module")
+}
+
+func TestSpoolingExporter_HangingReplay(t *testing.T) {
+	t.Parallel()
+
+	metrics, _ := newTestPipelineMetrics(t)
+	next := &hangingExporter{hanging: make(chan struct{}, 1)}
+
+	exp, err := newSpoolingExporter(exporterConfig{Name: "otlp", Timeout: "1h", Spool: spoolConfig{Directory: t.TempDir()}}, next, metrics, ignoreErrors)
+	require.NoError(t, err)
+
+	next.down.Store(true)
+	require.ErrorIs(t, exp.ExportSpans(context.Background(), recordSpans(t, "spooled")), errExport)
+
+	next.down.Store(false)
+	require.NoError(t, exp.ExportSpans(context.Background(), recordSpans(t, "live")))
+
+	select {
+	case <-next.hanging:
+	case <-time.After(time.Second):
+		require.FailNow(t, "the spooled batch is not replayed")
+	}
+
+	// the collector goes down again while the replay hangs, the failed export must not wait for the replay
+	next.down.Store(true)
+
+	exported := make(chan error, 1)
+
+	go func() { exported <- exp.ExportSpans(context.Background(), recordSpans(t, "failed")) }()
+
+	select {
+	case err := <-exported:
+		require.ErrorIs(t, err, errExport)
+	case <-time.After(time.Second):
+		require.FailNow(t, "the failed export is blocked by the replay")
+	}
+
+	assert.Len(t, exp.files(), 1, "the replayed batch is taken from the spool")
+
+	require.NoError(t, exp.Shutdown(context.Background()))
+	assert.Len(t, exp.files(), 2, "the cancelled replay restores its batch")
+}
+
+func TestSpoolingExporter_ReplayTimeout(t *testing.T) {
+	t.Parallel()
+
+	metrics, _ := newTestPipelineMetrics(t)
+	next := &hangingExporter{hanging: make(chan struct{}, 1)}
+
+	exp, err := newSpoolingExporter(exporterConfig{Name: "otlp", Timeout: "10ms", Spool: spoolConfig{Directory: t.TempDir()}}, next, metrics, ignoreErrors)
+	require.NoError(t, err)
+	t.Cleanup(func() { assert.NoError(t, exp.Shutdown(context.Background())) })
+
+	next.down.Store(true)
+	require.ErrorIs(t, exp.ExportSpans(context.Background(), recordSpans(t, "spooled")), errExport)
+
+	next.down.Store(false)
+	require.NoError(t, exp.ExportSpans(context.Background(), recordSpans(t, "live")))
+
+	<-next.hanging
+
+	// the replayed export ends with its timeout and the batch is spooled again
+	assert.Eventually(t, func() bool { return len(exp.files()) == 1 }, time.Second, time.Millisecond)
+}
+
+func TestEncodeSpans(t *testing.T) {
+	t.Parallel()
+
+	exp := tracetest.NewInMemoryExporter()
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithSyncer(exp),
+		tracesdk.WithResource(resource.NewSchemaless(semconv.ServiceName("shop"))),
+	)
+
+	ctx, parent := tp.Tracer("checkout", trace.WithInstrumentationVersion("1.0")).Start(context.Background(), "parent")
+	_, child := tp.Tracer("checkout").Start(ctx, "child", trace.WithSpanKind(trace.SpanKindClient), trace.WithAttributes(
+		attribute.String("string", "value"),
+		attribute.Int64("int", 42),
+		attribute.Bool("bool", true),
+		attribute.Float64Slice("floats", []float64{1.5, 2.5}),
+	))
+	child.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", 2)))
+	child.SetStatus(codes.Error, "declined")
+	child.End()
+	parent.End()
+
+	original := exp.GetSpans().Snapshots()
+
+	data, err := encodeSpans(original)
+	require.NoError(t, err)
+
+	path := filepath.Join(t.TempDir(), "batch.otlp")
+	require.NoError(t, writeFileAtomic(path, data))
+
+	decoded, err := readSpoolFile(path)
+	require.NoError(t, err)
+	require.Len(t, decoded, 2)
+
+	for i, want := range original {
+		got := decoded[i]
+
+		assert.Equal(t, want.Name(), got.Name())
+		assert.Equal(t, want.SpanContext(), got.SpanContext())
+		assert.Equal(t, want.Parent().SpanID(), got.Parent().SpanID())
+		assert.Equal(t, want.SpanKind(), got.SpanKind())
+		assert.True(t, want.StartTime().Equal(got.StartTime()))
+		assert.True(t, want.EndTime().Equal(got.EndTime()))
+		assert.ElementsMatch(t, want.Attributes(), got.Attributes())
+		assert.Equal(t, want.Status(), got.Status())
+		assert.Len(t, got.Events(), len(want.Events()))
+		assert.Equal(t, want.InstrumentationScope().Name, got.InstrumentationScope().Name)
+		assert.Equal(t, want.Resource().Attributes(), got.Resource().Attributes())
+	}
+
+	_, err = readSpoolFile(filepath.Join(t.TempDir(), "missing.otlp"))
+	assert.ErrorIs(t, err, errInvalidSpoolFile)
+}
@@ -0,0 +1,225 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/exporters/stdout/stdoutmetric"
+	"go.opentelemetry.io/otel/exporters/stdout/stdouttrace"
+	sdkMetric "go.opentelemetry.io/otel/sdk/metric"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+const (
+	protocolStdout = "stdout"
+
+	stdoutFormatPretty = "pretty"
+	stdoutFormatJSON   = "json"
+
+	mebibyte = 1 << 20
+)
+
+var errInvalidStdout = errors.New("invalid stdout exporter")
+
+type (
+	// stdoutConfig configures the stdout/file exporter for local development and CI
+	stdoutConfig struct {
+		Traces  bool   `json:"traces"`
+		Metrics bool   `json:"metrics"`
+		Format  string `json:"format"`
+		// Path of the output file, empty writes to stdout
+		Path string `json:"path"`
+		// MaxSize in MiB after which the file is rotated, 0 disables the rotation
+		MaxSize         int    `json:"maxSize"`
+		MaxBackups      int    `json:"maxBackups"`
+		MetricsInterval string `json:"metricsInterval"`
+		processorConfig
+	}
+
+	// rotatingFile is a file which is moved to path.1, path.2, ... once it exceeds maxSize
+	rotatingFile struct {
+		mu         sync.Mutex
+		path       string
+		maxSize    int64
+		maxBackups int
+		file       *os.File
+		size       int64
+	}
+)
+
+var _ io.WriteCloser = (*rotatingFile)(nil)
+
+// openStdout opens the output shared by the stdout trace and metric exporters
+func (m *Module) openStdout() error {
+	if m.disableExport || !m.stdout.Traces && !m.stdout.Metrics {
+		return nil
+	}
+
+	if m.stdout.Format != stdoutFormatPretty && m.stdout.Format != stdoutFormatJSON {
+		return fmt.Errorf("%w flamingo.opentelemetry.stdout.format: unknown format %q", errInvalidStdout, m.stdout.Format)
+	}
+
+	if m.stdout.Path == "" {
+		m.stdoutOutput = os.Stdout
+
+		return nil
+	}
+
+	file, err := newRotatingFile(m.stdout.Path, int64(m.stdout.MaxSize)*mebibyte, m.stdout.MaxBackups)
+	if err != nil {
+		return fmt.Errorf("%w flamingo.opentelemetry.stdout.path: %w", errInvalidStdout, err)
+	}
+
+	m.stdoutOutput = file
+	m.register(component{name: "stdout file", close: func(context.Context) error { return file.Close() }})
+
+	return nil
+}
+
+// newStdoutExporter creates the trace exporter writing to the output opened by openStdout
+func newStdoutExporter(cfg exporterConfig) (tracesdk.SpanExporter, error) {
+	opts := []stdouttrace.Option{stdouttrace.WithWriter(cfg.output)}
+	if cfg.pretty {
+		opts = append(opts, stdouttrace.WithPrettyPrint())
+	}
+
+	exp, err := stdouttrace.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize stdout exporter %s: %w", cfg.Name, err)
+	}
+
+	return exp, nil
+}
+
+// stdoutMetricReader periodically writes all metrics to the output opened by openStdout
+func (m *Module) stdoutMetricReader() (sdkMetric.Reader, error) {
+	interval, err := time.ParseDuration(m.stdout.MetricsInterval)
+	if err != nil {
+		return nil, fmt.Errorf("%w flamingo.opentelemetry.stdout.metricsInterval: %w", errInvalidStdout, err)
+	}
+
+	opts := []stdoutmetric.Option{stdoutmetric.WithWriter(m.stdoutOutput)}
+	if m.stdout.Format == stdoutFormatPretty {
+		opts = append(opts, stdoutmetric.WithPrettyPrint())
+	}
+
+	exp, err := stdoutmetric.New(opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to initialize stdout metric exporter: %w", err)
+	}
+
+	return sdkMetric.NewPeriodicReader(exp, sdkMetric.WithInterval(interval)), nil
+}
+
+func newRotatingFile(path string, maxSize int64, maxBackups int) (*rotatingFile, error) {
+	f := &rotatingFile{path: path, maxSize: maxSize, maxBackups: maxBackups}
+	if err := f.open(); err != nil {
+		return nil, err
+	}
+
+	return f, nil
+}
+
+func (f *rotatingFile) open() error {
+	file, err := os.OpenFile(f.path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644) //nolint:gosec,mnd // the path is configured by the operator, the file is meant to be read
+	if err != nil {
+		return fmt.Errorf("failed to open %s: %w", f.path, err)
+	}
+
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+
+		return fmt.Errorf("failed to stat %s: %w", f.path, err)
+	}
+
+	f.file = file
+	f.size = info.Size()
+
+	return nil
+}
+
+// rotate moves the current file to path.1 and the existing backups one number up, the oldest backup is removed.
+// The original path is reopened in any case, so a rotation failing partway keeps appending to the current file.
+func (f *rotatingFile) rotate() error {
+	var errs []error
+
+	if err := f.file.Close(); err != nil {
+		errs = append(errs, fmt.Errorf("failed to close %s: %w", f.path, err))
+	}
+
+	f.file = nil
+
+	_ = os.Remove(f.backup(f.maxBackups))
+
+	for i := f.maxBackups; i > 0; i-- {
+		if err := os.Rename(f.backup(i-1), f.backup(i)); err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, fmt.Errorf("failed to rotate %s: %w", f.path, err))
+
+			break
+		}
+	}
+
+	if err := f.open(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+func (f *rotatingFile) backup(n int) string {
+	if n == 0 {
+		return f.path
+	}
+
+	return f.path + "." + strconv.Itoa(n)
+}
+
+func (f *rotatingFile) Write(p []byte) (int, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	var rotateErr error
+
+	if f.file == nil {
+		if err := f.open(); err != nil {
+			return 0, err
+		}
+	} else if f.maxSize > 0 && f.size > 0 && f.size+int64(len(p)) > f.maxSize {
+		rotateErr = f.rotate()
+		// without a reopened file the data can not be written
+		if f.file == nil {
+			return 0, rotateErr
+		}
+	}
+
+	n, err := f.file.Write(p)
+	f.size += int64(n)
+
+	if err != nil {
+		return n, errors.Join(rotateErr, fmt.Errorf("failed to write %s: %w", f.path, err))
+	}
+
+	return n, rotateErr
+}
+
+func (f *rotatingFile) Close() error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	if f.file == nil {
+		return nil
+	}
+
+	if err := f.file.Close(); err != nil {
+		return fmt.Errorf("failed to close %s: %w", f.path, err)
+	}
+
+	return nil
+}
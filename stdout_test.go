@@ -0,0 +1,133 @@
+package opentelemetry //nolint:testpackage // explicit testing of private exporter setup
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+)
+
+func TestRotatingFile(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "telemetry.log")
+
+	f, err := newRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+
+	for _, line := range []string{"first\n", "second\n", "third\n", "fourth\n"} {
+		_, err = f.Write([]byte(line))
+		require.NoError(t, err)
+	}
+
+	require.NoError(t, f.Close())
+
+	for file, want := range map[string]string{
+		path:        "fourth\n",
+		path + ".1": "third\n",
+		path + ".2": "second\n",
+	} {
+		content, err := os.ReadFile(file)
+		require.NoError(t, err)
+		assert.Equal(t, want, string(content), file)
+	}
+
+	assert.NoFileExists(t, path+".3", "only maxBackups files are kept")
+}
+
+func TestRotatingFile_FailedRotation(t *testing.T) {
+	t.Parallel()
+
+	path := filepath.Join(t.TempDir(), "telemetry.log")
+
+	// a non-empty directory can neither be removed nor replaced by renaming the first backup
+	require.NoError(t, os.MkdirAll(filepath.Join(path+".2", "blocked"), 0o750))
+	require.NoError(t, os.WriteFile(path+".1", []byte("backup\n"), 0o600))
+
+	f, err := newRotatingFile(path, 10, 2)
+	require.NoError(t, err)
+
+	_, err = f.Write([]byte("first\n"))
+	require.NoError(t, err)
+
+	n, err := f.Write([]byte("second\n"))
+	require.Error(t, err)
+	assert.Equal(t, 7, n, "the current file is reopened and written")
+
+	_, err = f.Write([]byte("third\n"))
+	require.Error(t, err, "the rotation is retried")
+	require.NoError(t, f.Close())
+
+	content, err := os.ReadFile(path)
+	require.NoError(t, err)
+	assert.Equal(t, "first\nsecond\nthird\n", string(content))
+}
+
+func TestModule_Stdout(t *testing.T) {
+	t.Parallel()
+
+	metrics, _ := newTestPipelineMetrics(t)
+	path := filepath.Join(t.TempDir(), "traces.jsonl")
+
+	m := &Module{
+		metrics: metrics,
+		stdout: stdoutConfig{
+			Traces:          true,
+			Format:          stdoutFormatJSON,
+			Path:            path,
+			MetricsInterval: "10s",
+			processorConfig: processorConfig{Processor: processorSimple},
+		},
+	}
+
+	require.NoError(t, m.openStdout())
+
+	processors, err := m.initExporters()
+	require.NoError(t, err)
+	require.Len(t, processors, 1)
+
+	tp := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(processors[0]))
+
+	for _, name := range []string{"first", "second"} {
+		_, span := tp.Tracer("test").Start(context.Background(), name)
+		span.End()
+	}
+
+	require.NoError(t, tp.Shutdown(context.Background()))
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+
+	t.Cleanup(func() { _ = file.Close() })
+
+	var names []string
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var span struct{ Name string }
+
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &span), "every span is written as one JSON line")
+
+		names = append(names, span.Name)
+	}
+
+	assert.Equal(t, []string{"first", "second"}, names)
+}
+
+func TestModule_Stdout_Invalid(t *testing.T) {
+	t.Parallel()
+
+	m := &Module{stdout: stdoutConfig{Metrics: true, Format: "yaml"}}
+
+	err := m.openStdout()
+
+	require.ErrorIs(t, err, errInvalidStdout)
+	assert.ErrorContains(t, err, "flamingo.opentelemetry.stdout.format")
+	assert.Nil(t, m.stdoutOutput)
+}
@@ -0,0 +1,127 @@
+package opentelemetry
+
+import (
+	"context"
+	"errors"
+	"io"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+var (
+	templateKey = attribute.Key("flamingo.template.name")
+	partialsKey = attribute.Key("flamingo.template.partials")
+	partialKey  = attribute.Key("flamingo.template.partial")
+
+	errNoPartialRendering = errors.New("template engine does not support partial rendering")
+)
+
+type (
+	// templateConfig enables the template rendering spans, Partials if the bound engine supports partial rendering
+	templateConfig struct {
+		Enable   bool `json:"enable"`
+		Partials bool `json:"partials"`
+	}
+
+	// templateEngineInterceptor wraps the bound template engine, dingo sets the embedded original engine
+	templateEngineInterceptor struct {
+		flamingo.TemplateEngine
+		tracer trace.Tracer
+	}
+
+	// partialTemplateEngineInterceptor wraps a bound template engine with partial rendering, it shares the layout of
+	// templateEngineInterceptor as dingo sets the original engine as first field
+	partialTemplateEngineInterceptor templateEngineInterceptor
+)
+
+var (
+	_ flamingo.TemplateEngine        = (*templateEngineInterceptor)(nil)
+	_ flamingo.PartialTemplateEngine = (*partialTemplateEngineInterceptor)(nil)
+)
+
+// Inject dependencies
+func (t *templateEngineInterceptor) Inject(provider trace.TracerProvider) *templateEngineInterceptor {
+	t.tracer = provider.Tracer(tracerName)
+
+	return t
+}
+
+// start falls back to the global tracer provider if the interceptor was not injected
+func (t *templateEngineInterceptor) start(ctx context.Context, name string, attrs ...attribute.KeyValue) (context.Context, trace.Span) {
+	tracer := t.tracer
+	if tracer == nil {
+		tracer = otel.Tracer(tracerName)
+	}
+
+	return tracer.Start(ctx, name, trace.WithAttributes(attrs...))
+}
+
+func (t *templateEngineInterceptor) Render(ctx context.Context, name string, data any) (io.Reader, error) {
+	ctx, span := t.start(ctx, "render "+name, templateKey.String(name))
+	defer span.End()
+
+	reader, err := t.TemplateEngine.Render(ctx, name, data)
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err //nolint:wrapcheck // the interceptor keeps the errors of the template engine
+	}
+
+	return reader, nil
+}
+
+// Inject dependencies
+func (t *partialTemplateEngineInterceptor) Inject(provider trace.TracerProvider) *partialTemplateEngineInterceptor {
+	(*templateEngineInterceptor)(t).Inject(provider)
+
+	return t
+}
+
+func (t *partialTemplateEngineInterceptor) Render(ctx context.Context, name string, data any) (io.Reader, error) {
+	return (*templateEngineInterceptor)(t).Render(ctx, name, data)
+}
+
+// RenderPartials records a span for the partials with a child span per partial, the engine still renders all partials
+// in a single call so the child spans share its duration
+func (t *partialTemplateEngineInterceptor) RenderPartials(ctx context.Context, templateName string, data any, partials []string) (map[string]string, error) {
+	engine, ok := t.TemplateEngine.(flamingo.PartialTemplateEngine)
+	if !ok {
+		return nil, errNoPartialRendering
+	}
+
+	ctx, span := (*templateEngineInterceptor)(t).start(ctx, "render partials "+templateName,
+		templateKey.String(templateName), partialsKey.StringSlice(partials))
+	defer span.End()
+
+	children := make([]trace.Span, 0, len(partials))
+	for _, partial := range partials {
+		_, child := (*templateEngineInterceptor)(t).start(ctx, "render partial "+partial,
+			templateKey.String(templateName), partialKey.String(partial))
+		children = append(children, child)
+	}
+
+	rendered, err := engine.RenderPartials(ctx, templateName, data, partials)
+
+	for _, child := range children {
+		if err != nil {
+			child.SetStatus(codes.Error, err.Error())
+		}
+
+		child.End()
+	}
+
+	if err != nil {
+		span.RecordError(err)
+		span.SetStatus(codes.Error, err.Error())
+
+		return nil, err //nolint:wrapcheck // the interceptor keeps the errors of the template engine
+	}
+
+	return rendered, nil
+}
@@ -0,0 +1,108 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private template engine interceptor
+
+import (
+	"context"
+	"errors"
+	"io"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+var errTemplateNotFound = errors.New("template not found")
+
+type (
+	testTemplateEngine struct{}
+
+	testPartialTemplateEngine struct {
+		testTemplateEngine
+	}
+)
+
+func (testTemplateEngine) Render(_ context.Context, name string, _ any) (io.Reader, error) {
+	if name == "missing" {
+		return nil, errTemplateNotFound
+	}
+
+	return strings.NewReader(name), nil
+}
+
+func (testPartialTemplateEngine) RenderPartials(_ context.Context, _ string, _ any, partials []string) (map[string]string, error) {
+	result := make(map[string]string, len(partials))
+	for _, partial := range partials {
+		result[partial] = "<" + partial + ">"
+	}
+
+	return result, nil
+}
+
+func TestTemplateEngineInterceptor(t *testing.T) {
+	t.Parallel()
+
+	newInterceptor := func(t *testing.T, engine flamingo.TemplateEngine) (*templateEngineInterceptor, *tracetest.InMemoryExporter) {
+		t.Helper()
+
+		exp := tracetest.NewInMemoryExporter()
+		tp := tracesdk.NewTracerProvider(tracesdk.WithSyncer(exp))
+
+		return &templateEngineInterceptor{TemplateEngine: engine, tracer: tp.Tracer("test")}, exp
+	}
+
+	t.Run("render", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor, exp := newInterceptor(t, testTemplateEngine{})
+
+		reader, err := interceptor.Render(context.Background(), "product/view", nil)
+		require.NoError(t, err)
+		require.NotNil(t, reader)
+
+		_, err = interceptor.Render(context.Background(), "missing", nil)
+		require.ErrorIs(t, err, errTemplateNotFound)
+
+		spans := exp.GetSpans()
+		require.Len(t, spans, 2)
+		assert.Equal(t, "render product/view", spans[0].Name)
+		assert.Contains(t, spans[0].Attributes, templateKey.String("product/view"))
+		assert.Equal(t, codes.Error, spans[1].Status.Code)
+	})
+
+	t.Run("partials", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor, exp := newInterceptor(t, testPartialTemplateEngine{})
+
+		result, err := (*partialTemplateEngineInterceptor)(interceptor).RenderPartials(context.Background(), "checkout", nil, []string{"cart", "summary"})
+		require.NoError(t, err)
+		assert.Equal(t, map[string]string{"cart": "<cart>", "summary": "<summary>"}, result)
+
+		spans := exp.GetSpans()
+		require.Len(t, spans, 3)
+		assert.Equal(t, "render partial cart", spans[0].Name)
+		assert.Contains(t, spans[0].Attributes, partialKey.String("cart"))
+		assert.Equal(t, "render partial summary", spans[1].Name)
+		assert.Equal(t, spans[2].SpanContext.SpanID(), spans[1].Parent.SpanID())
+		assert.Equal(t, "render partials checkout", spans[2].Name)
+		assert.Contains(t, spans[2].Attributes, partialsKey.StringSlice([]string{"cart", "summary"}))
+	})
+
+	t.Run("engine without partial rendering", func(t *testing.T) {
+		t.Parallel()
+
+		interceptor, _ := newInterceptor(t, testTemplateEngine{})
+
+		var engine flamingo.TemplateEngine = interceptor
+		_, ok := engine.(flamingo.PartialTemplateEngine)
+		assert.False(t, ok, "the interceptor does not offer partial rendering")
+
+		_, err := (*partialTemplateEngineInterceptor)(interceptor).RenderPartials(context.Background(), "checkout", nil, []string{"cart"})
+		assert.ErrorIs(t, err, errNoPartialRendering)
+	})
+}
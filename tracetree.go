@@ -0,0 +1,227 @@
+package opentelemetry
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+
+	"flamingo.me/flamingo/v3/framework/flamingo"
+)
+
+const (
+	// maxTreeSpans limits the buffered spans per trace, further spans are left out of the tree
+	maxTreeSpans = 1000
+	// maxTreeTraces limits the buffered traces, spans of further traces are not buffered
+	maxTreeTraces = 1000
+	// treeTraceTTL removes traces whose local root never ends, e.g. because it was not sampled or not recorded
+	treeTraceTTL = 10 * time.Minute
+)
+
+// defaultTreeAttributes are printed when no attributes are configured
+var defaultTreeAttributes = []string{
+	"http.request.method",
+	"http.route",
+	"http.response.status_code",
+	"url.path",
+	"server.address",
+	"db.system.name",
+}
+
+type (
+	// traceTreeConfig configures the development trace tree printer
+	traceTreeConfig struct {
+		Enable     bool     `json:"enable"`
+		Threshold  string   `json:"threshold"`
+		Attributes []string `json:"attributes"`
+	}
+
+	// traceTreePrinter buffers the spans of a trace and logs them as tree once the local root server span ends,
+	// only requests taking at least threshold or containing failed spans are printed
+	traceTreePrinter struct {
+		logger     flamingo.Logger
+		threshold  time.Duration
+		attributes []string
+
+		mu     sync.Mutex
+		traces map[trace.TraceID]*treeTrace
+	}
+
+	// treeTrace holds the buffered spans of a trace and the end time of its latest span
+	treeTrace struct {
+		spans   []tracesdk.ReadOnlySpan
+		lastEnd time.Time
+	}
+)
+
+var _ tracesdk.SpanProcessor = (*traceTreePrinter)(nil)
+
+func newTraceTreePrinter(logger flamingo.Logger, cfg traceTreeConfig) (*traceTreePrinter, error) {
+	var threshold time.Duration
+
+	if cfg.Threshold != "" {
+		var err error
+
+		threshold, err = time.ParseDuration(cfg.Threshold)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse flamingo.opentelemetry.traceTree.threshold: %w", err)
+		}
+	}
+
+	attributes := cfg.Attributes
+	if len(attributes) == 0 {
+		attributes = defaultTreeAttributes
+	}
+
+	return &traceTreePrinter{
+		logger: logger.
+			WithField(flamingo.LogKeyModule, "opentelemetry").
+			WithField(flamingo.LogKeyCategory, "trace tree"),
+		threshold:  threshold,
+		attributes: attributes,
+		traces:     make(map[trace.TraceID]*treeTrace),
+	}, nil
+}
+
+func (*traceTreePrinter) OnStart(context.Context, tracesdk.ReadWriteSpan) {}
+
+func (p *traceTreePrinter) OnEnd(span tracesdk.ReadOnlySpan) {
+	traceID := span.SpanContext().TraceID()
+	localRoot := !span.Parent().IsValid() || span.Parent().IsRemote()
+
+	p.mu.Lock()
+
+	buffered, ok := p.traces[traceID]
+	if !ok {
+		buffered = new(treeTrace)
+	}
+
+	if len(buffered.spans) < maxTreeSpans {
+		buffered.spans = append(buffered.spans, span)
+	}
+
+	buffered.lastEnd = span.EndTime()
+
+	if !localRoot {
+		if !ok && p.hasRoom(span.EndTime()) {
+			p.traces[traceID] = buffered
+		}
+
+		p.mu.Unlock()
+
+		return
+	}
+
+	delete(p.traces, traceID)
+	p.mu.Unlock()
+
+	spans := buffered.spans
+
+	// spans of other local roots, e.g. background jobs, are dropped together with their root
+	if span.SpanKind() != trace.SpanKindServer {
+		return
+	}
+
+	failed := slices.ContainsFunc(spans, func(s tracesdk.ReadOnlySpan) bool { return s.Status().Code == codes.Error })
+	if !failed && span.EndTime().Sub(span.StartTime()) < p.threshold {
+		return
+	}
+
+	tree := p.format(span, spans)
+	if failed {
+		p.logger.Warn(tree)
+
+		return
+	}
+
+	p.logger.Info(tree)
+}
+
+// hasRoom reports whether another trace can be buffered, traces which did not change within the TTL are removed
+// once the limit is reached; the end time of the current span is used as clock
+func (p *traceTreePrinter) hasRoom(now time.Time) bool {
+	if len(p.traces) < maxTreeTraces {
+		return true
+	}
+
+	for traceID, buffered := range p.traces {
+		if now.Sub(buffered.lastEnd) > treeTraceTTL {
+			delete(p.traces, traceID)
+		}
+	}
+
+	return len(p.traces) < maxTreeTraces
+}
+
+func (p *traceTreePrinter) Shutdown(context.Context) error {
+	p.mu.Lock()
+	clear(p.traces)
+	p.mu.Unlock()
+
+	return nil
+}
+
+func (*traceTreePrinter) ForceFlush(context.Context) error { return nil }
+
+// format renders the root span and its descendants, children are ordered by their start time
+func (p *traceTreePrinter) format(root tracesdk.ReadOnlySpan, spans []tracesdk.ReadOnlySpan) string {
+	children := make(map[trace.SpanID][]tracesdk.ReadOnlySpan, len(spans))
+	for _, s := range spans {
+		children[s.Parent().SpanID()] = append(children[s.Parent().SpanID()], s)
+	}
+
+	for _, c := range children {
+		slices.SortFunc(c, func(a, b tracesdk.ReadOnlySpan) int { return a.StartTime().Compare(b.StartTime()) })
+	}
+
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "trace %s", root.SpanContext().TraceID())
+
+	var write func(span tracesdk.ReadOnlySpan, depth int)
+
+	write = func(span tracesdk.ReadOnlySpan, depth int) {
+		b.WriteString("\n")
+		b.WriteString(strings.Repeat("  ", depth))
+		p.writeSpan(&b, span)
+
+		for _, child := range children[span.SpanContext().SpanID()] {
+			write(child, depth+1)
+		}
+	}
+
+	write(root, 0)
+
+	return b.String()
+}
+
+func (p *traceTreePrinter) writeSpan(b *strings.Builder, span tracesdk.ReadOnlySpan) {
+	fmt.Fprintf(b, "%s %s %s", span.Name(), span.EndTime().Sub(span.StartTime()).Round(time.Microsecond), span.Status().Code)
+
+	if span.Status().Description != "" {
+		fmt.Fprintf(b, " (%s)", span.Status().Description)
+	}
+
+	for _, key := range p.attributes {
+		if kv, ok := findAttribute(span.Attributes(), key); ok {
+			fmt.Fprintf(b, " %s=%s", key, kv.Value.Emit())
+		}
+	}
+}
+
+func findAttribute(attrs []attribute.KeyValue, key string) (attribute.KeyValue, bool) {
+	for _, kv := range attrs {
+		if string(kv.Key) == key {
+			return kv, true
+		}
+	}
+
+	return attribute.KeyValue{}, false
+}
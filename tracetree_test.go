@@ -0,0 +1,122 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private trace tree printer
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/trace"
+)
+
+func TestTraceTreePrinter(t *testing.T) {
+	t.Parallel()
+
+	request := func(tp trace.TracerProvider, fail bool) {
+		start := time.Now()
+		tracer := tp.Tracer("test")
+
+		ctx, root := tracer.Start(context.Background(), "incoming request: /checkout",
+			trace.WithSpanKind(trace.SpanKindServer), trace.WithTimestamp(start),
+			trace.WithAttributes(attribute.String("http.route", "/checkout"), attribute.String("ignored", "value")))
+
+		controllerCtx, controller := tracer.Start(ctx, "controller", trace.WithTimestamp(start.Add(time.Millisecond)))
+		_, db := tracer.Start(controllerCtx, "db query", trace.WithTimestamp(start.Add(2*time.Millisecond)))
+
+		if fail {
+			db.SetStatus(codes.Error, "connection refused")
+		}
+
+		db.End(trace.WithTimestamp(start.Add(5 * time.Millisecond)))
+		controller.End(trace.WithTimestamp(start.Add(6 * time.Millisecond)))
+		_, render := tracer.Start(ctx, "render", trace.WithTimestamp(start.Add(7*time.Millisecond)))
+		render.End(trace.WithTimestamp(start.Add(8 * time.Millisecond)))
+		root.End(trace.WithTimestamp(start.Add(10 * time.Millisecond)))
+	}
+
+	t.Run("print slow requests", func(t *testing.T) {
+		t.Parallel()
+
+		logger := newRecordingLogger()
+		printer, err := newTraceTreePrinter(logger, traceTreeConfig{Threshold: "10ms"})
+		require.NoError(t, err)
+
+		request(tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(printer)), false)
+
+		messages := logger.Messages()
+		require.Len(t, messages, 1)
+		assert.Contains(t, messages[0], "\nincoming request: /checkout 10ms Unset http.route=/checkout\n"+
+			"  controller 5ms Unset\n"+
+			"    db query 3ms Unset\n"+
+			"  render 1ms Unset")
+		assert.NotContains(t, messages[0], "ignored")
+		assert.Empty(t, printer.traces, "the spans of printed traces are released")
+	})
+
+	t.Run("skip fast requests", func(t *testing.T) {
+		t.Parallel()
+
+		logger := newRecordingLogger()
+		printer, err := newTraceTreePrinter(logger, traceTreeConfig{Threshold: "1s"})
+		require.NoError(t, err)
+
+		request(tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(printer)), false)
+
+		assert.Empty(t, logger.Messages())
+		assert.Empty(t, printer.traces)
+	})
+
+	t.Run("print failing requests below the threshold", func(t *testing.T) {
+		t.Parallel()
+
+		logger := newRecordingLogger()
+		printer, err := newTraceTreePrinter(logger, traceTreeConfig{Threshold: "1s"})
+		require.NoError(t, err)
+
+		request(tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(printer)), true)
+
+		messages := logger.Messages()
+		require.Len(t, messages, 1)
+		assert.Contains(t, messages[0], "db query 3ms Error (connection refused)")
+	})
+
+	t.Run("limit the traces without an ended root", func(t *testing.T) {
+		t.Parallel()
+
+		printer, err := newTraceTreePrinter(newRecordingLogger(), traceTreeConfig{})
+		require.NoError(t, err)
+
+		tracer := tracesdk.NewTracerProvider(tracesdk.WithSpanProcessor(printer)).Tracer("test")
+		start := time.Now()
+
+		orphan := func(end time.Time) {
+			// the root never ends, so its trace stays buffered
+			ctx, _ := tracer.Start(context.Background(), "never ended")
+			_, child := tracer.Start(ctx, "child")
+			child.End(trace.WithTimestamp(end))
+		}
+
+		for range maxTreeTraces + 1 {
+			orphan(start)
+		}
+
+		assert.Len(t, printer.traces, maxTreeTraces)
+
+		orphan(start.Add(treeTraceTTL + time.Second))
+		assert.Len(t, printer.traces, 1, "expired traces are removed")
+
+		require.NoError(t, printer.Shutdown(context.Background()))
+		assert.Empty(t, printer.traces)
+	})
+
+	t.Run("invalid threshold", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := newTraceTreePrinter(newRecordingLogger(), traceTreeConfig{Threshold: "slow"})
+		assert.ErrorContains(t, err, "flamingo.opentelemetry.traceTree.threshold")
+	})
+}
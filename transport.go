@@ -0,0 +1,261 @@
+package opentelemetry
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"net"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"time"
+
+	"golang.org/x/net/http/httpproxy"
+)
+
+const (
+	defaultIdleConnTimeout = 90 * time.Second
+	defaultMaxIdleConns    = 100
+)
+
+var (
+	errInvalidTransport = errors.New("invalid exporter transport")
+	errProxyConnect     = errors.New("proxy refused the connection")
+)
+
+type (
+	// proxyConn reads the data the proxy sent after its CONNECT response before reading from the connection
+	proxyConn struct {
+		net.Conn
+
+		reader *bufio.Reader
+	}
+
+	// transportConfig configures the HTTP connections of the exporters, independent of http.DefaultTransport and the
+	// proxy environment variables, zero values keep the defaults
+	transportConfig struct {
+		Proxy               string   `json:"proxy"`
+		NoProxy             []string `json:"noProxy"`
+		MaxIdleConns        int      `json:"maxIdleConns"`
+		MaxIdleConnsPerHost int      `json:"maxIdleConnsPerHost"`
+		MaxConnsPerHost     int      `json:"maxConnsPerHost"`
+		IdleConnTimeout     string   `json:"idleConnTimeout"`
+		// Certificate is a PEM file of the CAs trusted for the collectors, ClientCertificate and ClientKey are the PEM
+		// files of the client certificate for mTLS
+		Certificate       string `json:"certificate"`
+		ClientCertificate string `json:"clientCertificate"`
+		ClientKey         string `json:"clientKey"`
+	}
+)
+
+// transport creates the uninstrumented transport shared by the exporters, without proxy unless one is configured
+func (cfg transportConfig) transport() (*http.Transport, error) {
+	const key = "flamingo.opentelemetry.exporterTransport"
+
+	var errs []error
+
+	transport := &http.Transport{
+		DialContext: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).DialContext,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          defaultMaxIdleConns,
+		MaxIdleConnsPerHost:   cfg.MaxIdleConnsPerHost,
+		MaxConnsPerHost:       cfg.MaxConnsPerHost,
+		IdleConnTimeout:       defaultIdleConnTimeout,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: time.Second,
+	}
+
+	if cfg.MaxIdleConns > 0 {
+		transport.MaxIdleConns = cfg.MaxIdleConns
+	}
+
+	if cfg.MaxIdleConns < 0 || cfg.MaxIdleConnsPerHost < 0 || cfg.MaxConnsPerHost < 0 {
+		errs = append(errs, fmt.Errorf("%w %s: connection limits must not be negative", errInvalidTransport, key))
+	}
+
+	if cfg.IdleConnTimeout != "" {
+		timeout, err := time.ParseDuration(cfg.IdleConnTimeout)
+		if err != nil || timeout <= 0 {
+			errs = append(errs, fmt.Errorf("%w %s.idleConnTimeout: %q must be a positive duration", errInvalidTransport, key, cfg.IdleConnTimeout))
+		}
+
+		transport.IdleConnTimeout = timeout
+	}
+
+	if cfg.Proxy != "" {
+		proxy, err := url.Parse(cfg.Proxy)
+		if err != nil || proxy.Host == "" {
+			errs = append(errs, fmt.Errorf("%w %s.proxy: %q must be an absolute URL", errInvalidTransport, key, cfg.Proxy))
+		} else {
+			proxyFunc := (&httpproxy.Config{
+				HTTPProxy:  cfg.Proxy,
+				HTTPSProxy: cfg.Proxy,
+				NoProxy:    strings.Join(cfg.NoProxy, ","),
+			}).ProxyFunc()
+
+			transport.Proxy = func(req *http.Request) (*url.URL, error) {
+				return proxyFunc(req.URL) //nolint:wrapcheck // passed unchanged to the transport
+			}
+		}
+	}
+
+	tlsConfig, err := cfg.tlsConfig(key)
+	if err != nil {
+		errs = append(errs, err)
+	}
+
+	transport.TLSClientConfig = tlsConfig
+
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	return transport, nil
+}
+
+// tlsConfig loads the configured certificates, it is nil without certificates so that the system CAs are used
+func (cfg transportConfig) tlsConfig(key string) (*tls.Config, error) {
+	if cfg.Certificate == "" && cfg.ClientCertificate == "" && cfg.ClientKey == "" {
+		return nil, nil //nolint:nilnil // no TLS settings are configured
+	}
+
+	tlsConfig := &tls.Config{MinVersion: tls.VersionTLS12}
+
+	var errs []error
+
+	if cfg.Certificate != "" {
+		pem, err := os.ReadFile(cfg.Certificate)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w %s.certificate: %w", errInvalidTransport, key, err))
+		} else {
+			tlsConfig.RootCAs = x509.NewCertPool()
+			if !tlsConfig.RootCAs.AppendCertsFromPEM(pem) {
+				errs = append(errs, fmt.Errorf("%w %s.certificate: %q contains no PEM certificate", errInvalidTransport, key, cfg.Certificate))
+			}
+		}
+	}
+
+	if cfg.ClientCertificate != "" || cfg.ClientKey != "" {
+		certificate, err := tls.LoadX509KeyPair(cfg.ClientCertificate, cfg.ClientKey)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("%w %s.clientCertificate: %w", errInvalidTransport, key, err))
+		} else {
+			tlsConfig.Certificates = []tls.Certificate{certificate}
+		}
+	}
+
+	return tlsConfig, errors.Join(errs...)
+}
+
+// dialGRPC connects the gRPC exporter like the HTTP exporters, through the proxy of the transport with HTTP CONNECT
+// unless the collector is excluded by noProxy
+func dialGRPC(transport *http.Transport) func(ctx context.Context, addr string) (net.Conn, error) {
+	return func(ctx context.Context, addr string) (net.Conn, error) {
+		var proxy *url.URL
+
+		if transport.Proxy != nil {
+			var err error
+
+			proxy, err = transport.Proxy(&http.Request{URL: &url.URL{Scheme: "https", Host: addr}})
+			if err != nil {
+				return nil, fmt.Errorf("failed to find the proxy for %s: %w", addr, err)
+			}
+		}
+
+		if proxy == nil {
+			conn, err := transport.DialContext(ctx, "tcp", addr)
+			if err != nil {
+				return nil, fmt.Errorf("failed to connect to %s: %w", addr, err)
+			}
+
+			return conn, nil
+		}
+
+		proxyAddr := proxy.Host
+		if proxy.Port() == "" {
+			proxyAddr = net.JoinHostPort(proxy.Hostname(), "80")
+		}
+
+		conn, err := transport.DialContext(ctx, "tcp", proxyAddr)
+		if err != nil {
+			return nil, fmt.Errorf("failed to connect to the proxy %s: %w", proxyAddr, err)
+		}
+
+		tunnel, err := connect(ctx, conn, proxy, addr)
+		if err != nil {
+			_ = conn.Close()
+
+			return nil, err
+		}
+
+		return tunnel, nil
+	}
+}
+
+// connect opens a tunnel to addr through the proxy connection
+func connect(ctx context.Context, conn net.Conn, proxy *url.URL, addr string) (net.Conn, error) {
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetDeadline(deadline)
+		defer func() { _ = conn.SetDeadline(time.Time{}) }()
+	}
+
+	req := &http.Request{
+		Method: http.MethodConnect,
+		URL:    &url.URL{Opaque: addr},
+		Host:   addr,
+		Header: make(http.Header),
+	}
+
+	if proxy.User != nil {
+		password, _ := proxy.User.Password()
+		credentials := base64.StdEncoding.EncodeToString([]byte(proxy.User.Username() + ":" + password))
+		req.Header.Set("Proxy-Authorization", "Basic "+credentials)
+	}
+
+	if err := req.Write(conn); err != nil {
+		return nil, fmt.Errorf("failed to connect to %s through the proxy: %w", addr, err)
+	}
+
+	reader := bufio.NewReader(conn)
+
+	resp, err := http.ReadResponse(reader, req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to %s through the proxy: %w", addr, err)
+	}
+
+	_ = resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("%w to %s: %s", errProxyConnect, addr, resp.Status)
+	}
+
+	return &proxyConn{Conn: conn, reader: reader}, nil
+}
+
+func (c *proxyConn) Read(b []byte) (int, error) {
+	return c.reader.Read(b) //nolint:wrapcheck // the connection is transparent to gRPC
+}
+
+// exporterTransport returns the transport of the exporter, the default transport settings are used if none was set
+func (cfg exporterConfig) exporterTransport() *http.Transport {
+	if cfg.transport == nil {
+		transport, _ := transportConfig{}.transport()
+
+		return transport
+	}
+
+	return cfg.transport
+}
+
+// httpClient returns a client using the exporter transport
+func (cfg exporterConfig) httpClient(timeout time.Duration) *http.Client {
+	return &http.Client{Transport: cfg.exporterTransport(), Timeout: timeout}
+}
@@ -0,0 +1,243 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private exporter transport
+
+import (
+	"bufio"
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"io"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"net/http/httptest"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTransportConfig_Transport(t *testing.T) {
+	t.Parallel()
+
+	t.Run("defaults ignore the proxy environment", func(t *testing.T) {
+		t.Parallel()
+
+		transport, err := transportConfig{}.transport()
+		require.NoError(t, err)
+		assert.Nil(t, transport.Proxy)
+		assert.Equal(t, defaultMaxIdleConns, transport.MaxIdleConns)
+		assert.Equal(t, defaultIdleConnTimeout, transport.IdleConnTimeout)
+	})
+
+	t.Run("limits and proxy", func(t *testing.T) {
+		t.Parallel()
+
+		transport, err := transportConfig{
+			Proxy:           "http://proxy.internal:3128",
+			NoProxy:         []string{".cluster.local", "collector.example.com"},
+			MaxIdleConns:    10,
+			MaxConnsPerHost: 2,
+			IdleConnTimeout: "30s",
+		}.transport()
+		require.NoError(t, err)
+		assert.Equal(t, 10, transport.MaxIdleConns)
+		assert.Equal(t, 2, transport.MaxConnsPerHost)
+		assert.Equal(t, 30*time.Second, transport.IdleConnTimeout)
+
+		for target, want := range map[string]string{
+			"https://tempo.example.com/v1/traces":       "http://proxy.internal:3128",
+			"http://otel.monitoring.cluster.local:4318": "",
+			"https://collector.example.com/v1/traces":   "",
+		} {
+			req, err := http.NewRequestWithContext(context.Background(), http.MethodPost, target, nil)
+			require.NoError(t, err)
+
+			proxy, err := transport.Proxy(req)
+			require.NoError(t, err)
+
+			if want == "" {
+				assert.Nil(t, proxy, target)
+			} else {
+				assert.Equal(t, want, proxy.String(), target)
+			}
+		}
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		t.Parallel()
+
+		_, err := transportConfig{Proxy: "proxy", MaxConnsPerHost: -1, IdleConnTimeout: "forever"}.transport()
+		require.ErrorIs(t, err, errInvalidTransport)
+		assert.ErrorContains(t, err, `exporterTransport.proxy: "proxy" must be an absolute URL`)
+		assert.ErrorContains(t, err, "connection limits must not be negative")
+		assert.ErrorContains(t, err, `exporterTransport.idleConnTimeout: "forever" must be a positive duration`)
+	})
+}
+
+func TestTransportConfig_TLS(t *testing.T) {
+	t.Parallel()
+
+	dir := t.TempDir()
+	certificate, key := writeCertificate(t, dir)
+
+	transport, err := transportConfig{Certificate: certificate, ClientCertificate: certificate, ClientKey: key}.transport()
+	require.NoError(t, err)
+	require.NotNil(t, transport.TLSClientConfig)
+	assert.NotNil(t, transport.TLSClientConfig.RootCAs)
+	assert.Len(t, transport.TLSClientConfig.Certificates, 1)
+
+	transport, err = transportConfig{}.transport()
+	require.NoError(t, err)
+	assert.Nil(t, transport.TLSClientConfig, "the system CAs are used by default")
+
+	_, err = transportConfig{Certificate: key, ClientCertificate: certificate, ClientKey: filepath.Join(dir, "missing.key")}.transport()
+	require.ErrorIs(t, err, errInvalidTransport)
+	assert.ErrorContains(t, err, "exporterTransport.certificate: "+`"`+key+`" contains no PEM certificate`)
+	assert.ErrorContains(t, err, "exporterTransport.clientCertificate")
+}
+
+// writeCertificate writes a self-signed certificate and its key as PEM files to dir
+func writeCertificate(t *testing.T, dir string) (string, string) {
+	t.Helper()
+
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: "flamingo"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IsCA:         true,
+		KeyUsage:     x509.KeyUsageCertSign | x509.KeyUsageDigitalSignature,
+	}
+
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+
+	certificatePath := filepath.Join(dir, "client.pem")
+	keyPath := filepath.Join(dir, "client.key")
+
+	require.NoError(t, os.WriteFile(certificatePath, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0o600))
+	require.NoError(t, os.WriteFile(keyPath, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0o600))
+
+	return certificatePath, keyPath
+}
+
+func TestZipkinExporter_Proxy(t *testing.T) {
+	t.Parallel()
+
+	var host atomic.Value
+
+	proxy := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		host.Store(r.Host)
+		w.WriteHeader(http.StatusAccepted)
+	}))
+	t.Cleanup(proxy.Close)
+
+	transport, err := transportConfig{Proxy: proxy.URL}.transport()
+	require.NoError(t, err)
+
+	exp, err := newSpanExporter(exporterConfig{
+		Name:      "zipkin",
+		Protocol:  protocolZipkin,
+		Endpoint:  "http://zipkin.example.com/api/v2/spans",
+		transport: transport,
+	})
+	require.NoError(t, err)
+
+	require.NoError(t, exp.ExportSpans(context.Background(), recordSpans(t, "span")))
+	assert.Equal(t, "zipkin.example.com", host.Load(), "the request is sent to the proxy")
+}
+
+func TestDialGRPC(t *testing.T) {
+	t.Parallel()
+
+	collector := listen(t, func(conn net.Conn) {
+		_, _ = io.Copy(conn, conn)
+	})
+
+	var connected atomic.Value
+
+	proxy := listen(t, func(conn net.Conn) {
+		reader := bufio.NewReader(conn)
+
+		req, err := http.ReadRequest(reader)
+		if err != nil {
+			return
+		}
+
+		connected.Store(req.Method + " " + req.Host)
+
+		target, err := net.Dial("tcp", collector)
+		if err != nil {
+			return
+		}
+
+		defer target.Close()
+
+		_, _ = io.WriteString(conn, "HTTP/1.1 200 Connection established\r\n\r\n")
+
+		go func() { _, _ = io.Copy(target, reader) }()
+
+		_, _ = io.Copy(conn, target)
+	})
+
+	// loopback addresses are never proxied, the proxy connects every tunnel to the collector
+	transport, err := transportConfig{Proxy: "http://" + proxy, NoProxy: []string{"excluded.invalid"}}.transport()
+	require.NoError(t, err)
+
+	conn, err := dialGRPC(transport)(context.Background(), "collector.invalid:4317")
+	require.NoError(t, err)
+
+	defer conn.Close()
+
+	_, err = io.WriteString(conn, "ping")
+	require.NoError(t, err)
+
+	buf := make([]byte, 4)
+	_, err = io.ReadFull(conn, buf)
+	require.NoError(t, err)
+	assert.Equal(t, "ping", string(buf))
+	assert.Equal(t, "CONNECT collector.invalid:4317", connected.Load())
+
+	_, err = dialGRPC(transport)(context.Background(), "excluded.invalid:4317")
+	require.ErrorContains(t, err, "failed to connect to excluded.invalid:4317", "excluded collectors are dialed directly")
+}
+
+// listen serves every connection of a local listener with handle and returns its address
+func listen(t *testing.T, handle func(conn net.Conn)) string {
+	t.Helper()
+
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	t.Cleanup(func() { _ = listener.Close() })
+
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+
+			go func() {
+				defer conn.Close()
+
+				handle(conn)
+			}()
+		}
+	}()
+
+	return listener.Addr().String()
+}
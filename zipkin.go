@@ -0,0 +1,227 @@
+package opentelemetry
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"sync/atomic"
+
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+var errZipkinStatus = errors.New("zipkin rejected the spans")
+
+type (
+	// zipkinExporter sends spans in the Zipkin v2 JSON format, it replaces the deprecated
+	// go.opentelemetry.io/otel/exporters/zipkin for backends which do not accept OTLP yet
+	zipkinExporter struct {
+		endpoint string
+		headers  map[string]string
+		client   *http.Client
+		stopped  atomic.Bool
+	}
+
+	// zipkinSpan is the Zipkin v2 span model, see https://zipkin.io/zipkin-api/#/default/post_spans
+	zipkinSpan struct {
+		TraceID        string             `json:"traceId"`
+		ID             string             `json:"id"`
+		ParentID       string             `json:"parentId,omitempty"`
+		Name           string             `json:"name,omitempty"`
+		Kind           string             `json:"kind,omitempty"`
+		Timestamp      int64              `json:"timestamp"`
+		Duration       int64              `json:"duration,omitempty"`
+		LocalEndpoint  *zipkinEndpoint    `json:"localEndpoint,omitempty"`
+		RemoteEndpoint *zipkinEndpoint    `json:"remoteEndpoint,omitempty"`
+		Annotations    []zipkinAnnotation `json:"annotations,omitempty"`
+		Tags           map[string]string  `json:"tags,omitempty"`
+	}
+
+	zipkinEndpoint struct {
+		ServiceName string `json:"serviceName,omitempty"`
+		IPv4        string `json:"ipv4,omitempty"`
+		IPv6        string `json:"ipv6,omitempty"`
+		Port        int64  `json:"port,omitempty"`
+	}
+
+	zipkinAnnotation struct {
+		Timestamp int64  `json:"timestamp"`
+		Value     string `json:"value"`
+	}
+)
+
+var _ tracesdk.SpanExporter = (*zipkinExporter)(nil)
+
+// zipkinKinds maps the span kinds known to Zipkin, internal spans have no kind
+var zipkinKinds = map[trace.SpanKind]string{
+	trace.SpanKindServer:   "SERVER",
+	trace.SpanKindClient:   "CLIENT",
+	trace.SpanKindProducer: "PRODUCER",
+	trace.SpanKindConsumer: "CONSUMER",
+}
+
+// Create the Zipkin exporter
+func newZipkinExporter(cfg exporterConfig) (tracesdk.SpanExporter, error) {
+	if _, err := parseEndpointURL(cfg.configKey+".endpoint", cfg.Endpoint); err != nil {
+		return nil, err
+	}
+
+	return &zipkinExporter{
+		endpoint: cfg.Endpoint,
+		headers:  cfg.Headers,
+		client:   cfg.httpClient(0),
+	}, nil
+}
+
+func (e *zipkinExporter) ExportSpans(ctx context.Context, spans []tracesdk.ReadOnlySpan) error {
+	if e.stopped.Load() || len(spans) == 0 {
+		return nil
+	}
+
+	models := make([]zipkinSpan, 0, len(spans))
+	for _, span := range spans {
+		models = append(models, toZipkinSpan(span))
+	}
+
+	body, err := json.Marshal(models)
+	if err != nil {
+		return fmt.Errorf("failed to encode zipkin spans: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, e.endpoint, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to create zipkin request: %w", err)
+	}
+
+	req.Header.Set("Content-Type", "application/json")
+
+	for key, value := range e.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := e.client.Do(req)
+	if err != nil {
+		return fmt.Errorf("failed to send spans to zipkin: %w", err)
+	}
+
+	defer resp.Body.Close()
+
+	_, _ = io.Copy(io.Discard, resp.Body)
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("%w: %s responded %s", errZipkinStatus, e.endpoint, resp.Status)
+	}
+
+	return nil
+}
+
+// Shutdown stops the exporter, later exports are dropped
+func (e *zipkinExporter) Shutdown(context.Context) error {
+	e.stopped.Store(true)
+
+	return nil
+}
+
+func toZipkinSpan(span tracesdk.ReadOnlySpan) zipkinSpan {
+	model := zipkinSpan{
+		TraceID:   span.SpanContext().TraceID().String(),
+		ID:        span.SpanContext().SpanID().String(),
+		Name:      span.Name(),
+		Kind:      zipkinKinds[span.SpanKind()],
+		Timestamp: span.StartTime().UnixMicro(),
+		Duration:  span.EndTime().Sub(span.StartTime()).Microseconds(),
+		Tags:      make(map[string]string, len(span.Attributes())+4), //nolint:mnd // status and scope tags
+	}
+
+	if span.Parent().IsValid() {
+		model.ParentID = span.Parent().SpanID().String()
+	}
+
+	if service, ok := span.Resource().Set().Value(semconv.ServiceNameKey); ok {
+		model.LocalEndpoint = &zipkinEndpoint{ServiceName: service.AsString()}
+	}
+
+	model.RemoteEndpoint = zipkinRemoteEndpoint(span)
+
+	for _, kv := range span.Attributes() {
+		model.Tags[string(kv.Key)] = kv.Value.Emit()
+	}
+
+	switch span.Status().Code {
+	case codes.Error:
+		model.Tags["otel.status_code"] = "ERROR"
+		model.Tags["error"] = span.Status().Description
+	case codes.Ok:
+		model.Tags["otel.status_code"] = "OK"
+	case codes.Unset:
+	}
+
+	if scope := span.InstrumentationScope(); scope.Name != "" {
+		model.Tags["otel.scope.name"] = scope.Name
+		if scope.Version != "" {
+			model.Tags["otel.scope.version"] = scope.Version
+		}
+	}
+
+	for _, event := range span.Events() {
+		value := event.Name
+		if len(event.Attributes) > 0 {
+			attrs := make(map[string]string, len(event.Attributes))
+			for _, kv := range event.Attributes {
+				attrs[string(kv.Key)] = kv.Value.Emit()
+			}
+
+			encoded, _ := json.Marshal(attrs) // a map of strings is always encodable
+			value += ": " + string(encoded)
+		}
+
+		model.Annotations = append(model.Annotations, zipkinAnnotation{Timestamp: event.Time.UnixMicro(), Value: value})
+	}
+
+	return model
+}
+
+// zipkinRemoteEndpoint describes the peer of client and producer spans
+func zipkinRemoteEndpoint(span tracesdk.ReadOnlySpan) *zipkinEndpoint {
+	if span.SpanKind() != trace.SpanKindClient && span.SpanKind() != trace.SpanKindProducer {
+		return nil
+	}
+
+	var (
+		endpoint zipkinEndpoint
+		found    bool
+	)
+
+	for _, kv := range span.Attributes() {
+		switch kv.Key {
+		case attribute.Key("peer.service"):
+			endpoint.ServiceName, found = kv.Value.AsString(), true
+		case semconv.NetworkPeerAddressKey:
+			if ip := net.ParseIP(kv.Value.AsString()); ip != nil {
+				found = true
+
+				if ip.To4() != nil {
+					endpoint.IPv4 = ip.String()
+				} else {
+					endpoint.IPv6 = ip.String()
+				}
+			}
+		case semconv.NetworkPeerPortKey:
+			endpoint.Port = kv.Value.AsInt64()
+		}
+	}
+
+	if !found {
+		return nil
+	}
+
+	return &endpoint
+}
@@ -0,0 +1,133 @@
+package opentelemetry //nolint:testpackage // explicit testing of the private zipkin exporter
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/sdk/resource"
+	tracesdk "go.opentelemetry.io/otel/sdk/trace"
+	semconv "go.opentelemetry.io/otel/semconv/v1.40.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+// zipkinStandIn records the spans posted to the Zipkin v2 API
+type zipkinStandIn struct {
+	mu      sync.Mutex
+	spans   []zipkinSpan
+	headers http.Header
+	status  int
+}
+
+func (z *zipkinStandIn) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	z.mu.Lock()
+	defer z.mu.Unlock()
+
+	if r.Method != http.MethodPost || r.URL.Path != "/api/v2/spans" || r.Header.Get("Content-Type") != "application/json" {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	var spans []zipkinSpan
+	if err := json.NewDecoder(r.Body).Decode(&spans); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+
+		return
+	}
+
+	z.spans = append(z.spans, spans...)
+	z.headers = r.Header.Clone()
+
+	w.WriteHeader(z.status)
+}
+
+func TestZipkinExporter(t *testing.T) {
+	t.Parallel()
+
+	standIn := &zipkinStandIn{status: http.StatusAccepted}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+
+	exp, err := newZipkinExporter(exporterConfig{
+		Endpoint: server.URL + "/api/v2/spans",
+		Headers:  map[string]string{"Authorization": "Bearer secret"},
+	})
+	require.NoError(t, err)
+
+	tp := tracesdk.NewTracerProvider(
+		tracesdk.WithResource(resource.NewSchemaless(semconv.ServiceName("shop"))),
+		tracesdk.WithSyncer(exp),
+	)
+
+	ctx, serverSpan := tp.Tracer("checkout", trace.WithInstrumentationVersion("1.0")).Start(context.Background(), "incoming request",
+		trace.WithSpanKind(trace.SpanKindServer))
+	_, client := tp.Tracer("checkout").Start(ctx, "payment", trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(attribute.String("peer.service", "payment"), attribute.String("network.peer.address", "10.0.0.1"), attribute.Int("network.peer.port", 8080)))
+	client.AddEvent("retry", trace.WithAttributes(attribute.Int("attempt", 2)))
+	client.SetStatus(codes.Error, "payment declined")
+	client.End()
+	serverSpan.End()
+
+	standIn.mu.Lock()
+	defer standIn.mu.Unlock()
+
+	require.Len(t, standIn.spans, 2)
+	assert.Equal(t, "Bearer secret", standIn.headers.Get("Authorization"))
+
+	payment, incoming := standIn.spans[0], standIn.spans[1]
+
+	assert.Equal(t, "payment", payment.Name)
+	assert.Equal(t, "CLIENT", payment.Kind)
+	assert.Equal(t, incoming.TraceID, payment.TraceID)
+	assert.Equal(t, incoming.ID, payment.ParentID)
+	assert.Equal(t, &zipkinEndpoint{ServiceName: "shop"}, payment.LocalEndpoint)
+	assert.Equal(t, &zipkinEndpoint{ServiceName: "payment", IPv4: "10.0.0.1", Port: 8080}, payment.RemoteEndpoint)
+	assert.Equal(t, "ERROR", payment.Tags["otel.status_code"])
+	assert.Equal(t, "payment declined", payment.Tags["error"])
+	assert.Equal(t, "checkout", payment.Tags["otel.scope.name"])
+	require.Len(t, payment.Annotations, 1)
+	assert.Equal(t, `retry: {"attempt":"2"}`, payment.Annotations[0].Value)
+
+	assert.Equal(t, "SERVER", incoming.Kind)
+	assert.Empty(t, incoming.ParentID)
+	assert.Nil(t, incoming.RemoteEndpoint)
+	assert.Equal(t, "1.0", incoming.Tags["otel.scope.version"])
+	assert.NotZero(t, incoming.Timestamp)
+}
+
+func TestZipkinExporter_Errors(t *testing.T) {
+	t.Parallel()
+
+	standIn := &zipkinStandIn{status: http.StatusInternalServerError}
+	server := httptest.NewServer(standIn)
+	t.Cleanup(server.Close)
+
+	exp, err := newZipkinExporter(exporterConfig{Endpoint: server.URL + "/api/v2/spans"})
+	require.NoError(t, err)
+
+	spans := func() []tracesdk.ReadOnlySpan {
+		recorder := tracesdk.NewTracerProvider()
+		_, span := recorder.Tracer("test").Start(context.Background(), "span")
+		span.End()
+
+		return []tracesdk.ReadOnlySpan{span.(tracesdk.ReadOnlySpan)} //nolint:forcetypeassert // the SDK creates read only spans
+	}
+
+	err = exp.ExportSpans(context.Background(), spans())
+	require.ErrorIs(t, err, errZipkinStatus)
+	assert.ErrorContains(t, err, "500")
+
+	require.NoError(t, exp.Shutdown(context.Background()))
+	require.NoError(t, exp.ExportSpans(context.Background(), spans()), "spans are dropped after shutdown")
+
+	_, err = newZipkinExporter(exporterConfig{Endpoint: "localhost:9411", configKey: "flamingo.opentelemetry.zipkin"})
+	assert.ErrorIs(t, err, errInvalidEndpoint)
+}